@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	ctx "github.com/esifea/ai-driven-automation/internal/context"
+	"github.com/esifea/ai-driven-automation/internal/provider"
+	"github.com/esifea/ai-driven-automation/internal/role"
+)
+
+func runAsk(cfg *config.Config, args []string) error {
+	fs := newFlagSet("ask", "[flags] [question...]",
+		"Answer a question about the changes on the current branch and write\nthe answer to answer.md.")
+	fs.StringVar(&cfg.PRQuestion, "question", cfg.PRQuestion, "Question to answer, or pass it as arguments (env PR_QUESTION)")
+	fs.StringVar(&cfg.BaseBranch, "base", cfg.BaseBranch, "Base branch for the diff (env BASE_BRANCH)")
+	fs.StringVar(&cfg.CommentPath, "path", cfg.CommentPath, "File the question is about (env COMMENT_PATH)")
+	fs.StringVar(&cfg.CommentStartLine, "start-line", cfg.CommentStartLine, "First line the question is about (env COMMENT_START_LINE)")
+	fs.StringVar(&cfg.CommentEndLine, "end-line", cfg.CommentEndLine, "Last line the question is about (env COMMENT_END_LINE)")
+	addProviderFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		cfg.PRQuestion = strings.Join(fs.Args(), " ")
+	}
+
+	if err := requireFlags(fs, map[string]string{"question": cfg.PRQuestion}); err != nil {
+		return err
+	}
+
+	llm, err := setup(cfg, false)
+	if err != nil {
+		return err
+	}
+
+	return runQAMode(llm, cfg)
+}
+
+func runQAMode(llm provider.Provider, cfg *config.Config) error {
+	slog.Info("Q&A agent started", "question", cfg.PRQuestion)
+
+	overview := ctx.GetOverviewDoc()
+
+	// Get diff context (before/after changes)
+	diffCtx, err := ctx.GetDiffContext(cfg.BaseBranch)
+	if err != nil {
+		slog.Warn("Could not get diff context, falling back to current codebase context only", "error", err)
+		return runQAFallback(llm, cfg, overview)
+	}
+
+	slog.Info("Diff context loaded", "changed_files", len(diffCtx.ChangedFiles), "base", diffCtx.BaseBranch)
+
+	taskMetadata := &ctx.TaskMetadata{}
+	codebaseCtx := ctx.GetCodebaseContext(taskMetadata)
+
+	// === Pass 1: Analyze what files are needed ===
+	slog.Info("Q&A pass 1: analyzing question")
+
+	analysis, err := role.RunAnalysis(
+		rootCtx, llm,
+		qaAnalysisRequest(cfg, diffCtx, codebaseCtx, overview),
+	)
+
+	var additionalFiles map[string]string
+	if err != nil {
+		slog.Warn("Q&A analysis failed", "error", err)
+	} else {
+		additionalPaths := analysis.GetAdditionalFilePaths()
+		if len(additionalPaths) > 0 {
+			slog.Info("Pass 1 identified files needed", "files", additionalPaths)
+			additionalFiles = diffCtx.LoadAdditionalFiles(additionalPaths)
+		}
+	}
+
+	// === Pass 2: Answer with full context ===
+	slog.Info("Q&A pass 2: generating answer")
+
+	fullContext := qaAnswerContext(cfg, diffCtx, codebaseCtx, additionalFiles)
+
+	answer, err := role.RunQA(
+		rootCtx, llm, cfg,
+		fullContext, overview,
+	)
+	if err != nil {
+		return fmt.Errorf("Q&A failed: %w", err)
+	}
+
+	return writeAnswer(answer)
+}
+
+// qaAnalysisRequest builds the pass 1 request: diff + signatures
+func qaAnalysisRequest(cfg *config.Config, diffCtx *ctx.DiffContext, codebaseCtx *ctx.ContextType, overview string) *role.AnalysisRequest {
+	analysisContext := diffCtx.GetContextForQA(cfg.CommentPath, cfg.CommentEndLine)
+	analysisContext += "\n\n=== OTHER FILES (signatures) ===\n"
+	for path, sig := range codebaseCtx.SignatureFiles {
+		// Skip files already in diff
+		if _, inDiff := diffCtx.FilesAfter[path]; inDiff {
+			continue
+		}
+		analysisContext += fmt.Sprintf("--- %s ---\n%s\n\n", path, sig)
+	}
+
+	question := cfg.PRQuestion
+	if cfg.CommentPath != "" {
+		question = fmt.Sprintf("[File: %s, Line: %s] %s", cfg.CommentPath, cfg.CommentEndLine, question)
+	}
+
+	return &role.AnalysisRequest{
+		Mode:        role.AnalysisModeQA,
+		Instruction: question,
+		Context:     analysisContext,
+		Overview:    overview,
+	}
+}
+
+// qaAnswerContext builds the pass 2 context: diff + additional files + remaining signatures
+func qaAnswerContext(cfg *config.Config, diffCtx *ctx.DiffContext, codebaseCtx *ctx.ContextType, additionalFiles map[string]string) string {
+	// Build signatures string (excluding diff and additional files)
+	var signaturesStr string
+	for path, sig := range codebaseCtx.SignatureFiles {
+		if _, inDiff := diffCtx.FilesAfter[path]; inDiff {
+			continue
+		}
+		if _, inAdditional := additionalFiles[path]; inAdditional {
+			continue
+		}
+		signaturesStr += fmt.Sprintf("--- %s ---\n%s\n\n", path, sig)
+	}
+
+	return diffCtx.GetContextForQAWithAdditional(
+		cfg.CommentPath, cfg.CommentEndLine,
+		additionalFiles, signaturesStr,
+	)
+}
+
+// runQAFallback handles Q&A when diff context is not available
+func runQAFallback(llm provider.Provider, cfg *config.Config, overview string) error {
+	taskMetadata := &ctx.TaskMetadata{}
+	codebaseCtx := ctx.GetCodebaseContext(taskMetadata)
+
+	answer, err := role.RunQA(
+		rootCtx, llm, cfg,
+		codebaseCtx.GetContextForAnalysis(), overview,
+	)
+	if err != nil {
+		return fmt.Errorf("Q&A failed: %w", err)
+	}
+	return writeAnswer(answer)
+}
+
+func writeAnswer(answer string) error {
+	if err := os.WriteFile("answer.md", []byte(answer), 0644); err != nil {
+		return fmt.Errorf("failed to write answer: %w", err)
+	}
+	slog.Info("Answer written", "path", "answer.md")
+
+	fmt.Println(answer)
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	ctx "github.com/esifea/ai-driven-automation/internal/context"
+	"github.com/esifea/ai-driven-automation/internal/provider"
+	"github.com/esifea/ai-driven-automation/internal/role"
+)
+
+const chatHelp = `Commands:
+  :file path:line        Focus on a line (or path:start-end), like an inline PR comment
+  :file                  Clear the focus
+  :clear                 Forget the conversation history
+  :help                  Show this help
+  :quit                  Exit (or Ctrl-D)
+Anything else is sent as a question.
+`
+
+// chatSession keeps the diff and codebase context between turns
+type chatSession struct {
+	llm             provider.Provider
+	cfg             *config.Config
+	overview        string
+	diffCtx         *ctx.DiffContext // nil if no diff against base
+	codebaseCtx     *ctx.ContextType
+	additionalFiles map[string]string
+	history         []role.QATurn
+}
+
+func runChat(cfg *config.Config, args []string) error {
+	fs := newFlagSet("chat", "[--base BRANCH] [flags]",
+		"Start an interactive Q&A session about the working tree. The diff against\nthe base branch is built once; answers keep the conversation history.\n\n"+chatHelp)
+	fs.StringVar(&cfg.BaseBranch, "base", cfg.BaseBranch, "Base branch for the diff (env BASE_BRANCH)")
+	addProviderFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	llm, err := setup(cfg, false)
+	if err != nil {
+		return err
+	}
+
+	session := &chatSession{
+		llm:             llm,
+		cfg:             cfg,
+		overview:        ctx.GetOverviewDoc(),
+		codebaseCtx:     ctx.GetCodebaseContext(&ctx.TaskMetadata{}),
+		additionalFiles: make(map[string]string),
+	}
+
+	session.diffCtx, err = ctx.GetDiffContext(cfg.BaseBranch)
+	if err != nil {
+		slog.Warn("Could not get diff context, falling back to current codebase context only", "error", err)
+	} else {
+		slog.Info("Diff context loaded", "changed_files", len(session.diffCtx.ChangedFiles), "base", session.diffCtx.BaseBranch)
+	}
+
+	fmt.Fprint(os.Stderr, chatHelp)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	// Read in the background so Ctrl-C ends the session while waiting for input
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	for {
+		fmt.Fprint(os.Stderr, session.promptLabel())
+
+		var line string
+		var ok bool
+		select {
+		case <-rootCtx.Done():
+			fmt.Fprintln(os.Stderr)
+			return nil
+		case line, ok = <-lines:
+		}
+		if !ok {
+			fmt.Fprintln(os.Stderr)
+			return scanner.Err()
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			if quit := session.handleCommand(line); quit {
+				return nil
+			}
+			continue
+		}
+
+		answer, err := session.ask(line)
+		if rootCtx.Err() != nil {
+			fmt.Fprintln(os.Stderr)
+			return nil
+		}
+		if err != nil {
+			slog.Error("Question failed", "error", err)
+			continue
+		}
+
+		fmt.Println(answer)
+		fmt.Println()
+	}
+}
+
+func (s *chatSession) promptLabel() string {
+	if s.cfg.CommentPath == "" {
+		return "> "
+	}
+	return fmt.Sprintf("[%s:%s] > ", s.cfg.CommentPath, s.lineRange())
+}
+
+func (s *chatSession) lineRange() string {
+	if s.cfg.CommentStartLine != "" && s.cfg.CommentStartLine != s.cfg.CommentEndLine {
+		return s.cfg.CommentStartLine + "-" + s.cfg.CommentEndLine
+	}
+	return s.cfg.CommentEndLine
+}
+
+// handleCommand runs a :command, returning true to quit
+func (s *chatSession) handleCommand(line string) bool {
+	fields := strings.Fields(line)
+
+	switch fields[0] {
+	case ":quit", ":q", ":exit":
+		return true
+	case ":help":
+		fmt.Fprint(os.Stderr, chatHelp)
+	case ":clear":
+		s.history = nil
+		fmt.Fprintln(os.Stderr, "History cleared.")
+	case ":file":
+		if len(fields) < 2 {
+			s.cfg.CommentPath, s.cfg.CommentStartLine, s.cfg.CommentEndLine = "", "", ""
+			fmt.Fprintln(os.Stderr, "Focus cleared.")
+			return false
+		}
+		path, start, end, err := parseFileLocation(fields[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return false
+		}
+		s.cfg.CommentPath, s.cfg.CommentStartLine, s.cfg.CommentEndLine = path, start, end
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %s\n", fields[0])
+	}
+
+	return false
+}
+
+// parseFileLocation parses path:line or path:start-end
+func parseFileLocation(loc string) (path, start, end string, err error) {
+	idx := strings.LastIndex(loc, ":")
+	if idx <= 0 || idx == len(loc)-1 {
+		return "", "", "", fmt.Errorf("expected path:line or path:start-end, got %q", loc)
+	}
+
+	path, lines := loc[:idx], loc[idx+1:]
+	start, end, found := strings.Cut(lines, "-")
+	if !found {
+		end = start
+	}
+
+	first, err1 := strconv.Atoi(start)
+	last, err2 := strconv.Atoi(end)
+	if err1 != nil || err2 != nil || first < 1 || last < first {
+		return "", "", "", fmt.Errorf("invalid line range %q", lines)
+	}
+
+	return path, start, end, nil
+}
+
+// ask runs both Q&A passes for one question
+func (s *chatSession) ask(question string) (string, error) {
+	s.cfg.PRQuestion = question
+	conversation := role.FormatConversation(s.history)
+
+	var contextStr string
+	if s.diffCtx == nil {
+		contextStr = s.codebaseCtx.GetContextForAnalysis()
+	} else {
+		// === Pass 1: Analyze what files are needed ===
+		req := qaAnalysisRequest(s.cfg, s.diffCtx, s.codebaseCtx, s.overview)
+		req.Context = conversation + req.Context
+
+		analysis, err := role.RunAnalysis(rootCtx, s.llm, req)
+		if err != nil {
+			slog.Warn("Q&A analysis failed", "error", err)
+		} else if paths := analysis.GetAdditionalFilePaths(); len(paths) > 0 {
+			slog.Info("Pass 1 identified files needed", "files", paths)
+			for path, content := range s.diffCtx.LoadAdditionalFiles(paths) {
+				s.additionalFiles[path] = content
+			}
+		}
+
+		contextStr = qaAnswerContext(s.cfg, s.diffCtx, s.codebaseCtx, s.additionalFiles)
+	}
+
+	// === Pass 2: Answer with full context and history ===
+	answer, err := role.RunQA(rootCtx, s.llm, s.cfg, conversation+contextStr, s.overview)
+	if err != nil {
+		return "", fmt.Errorf("Q&A failed: %w", err)
+	}
+
+	s.history = append(s.history, role.QATurn{Question: question, Answer: answer})
+	return answer, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestParseFileLocation(t *testing.T) {
+	tests := []struct {
+		loc   string
+		path  string
+		start string
+		end   string
+		err   bool
+	}{
+		{loc: "main.go:12", path: "main.go", start: "12", end: "12"},
+		{loc: "cmd/agent/chat.go:3-7", path: "cmd/agent/chat.go", start: "3", end: "7"},
+		{loc: "C:/repo/a.go:5", path: "C:/repo/a.go", start: "5", end: "5"},
+		{loc: "main.go", err: true},
+		{loc: ":12", err: true},
+		{loc: "main.go:", err: true},
+		{loc: "main.go:x", err: true},
+		{loc: "main.go:3-", err: true},
+		{loc: "main.go:7-3", err: true},
+		{loc: "main.go:0", err: true},
+	}
+
+	for _, tt := range tests {
+		path, start, end, err := parseFileLocation(tt.loc)
+		if tt.err {
+			if err == nil {
+				t.Errorf("parseFileLocation(%q) should fail", tt.loc)
+			}
+			continue
+		}
+		if err != nil || path != tt.path || start != tt.start || end != tt.end {
+			t.Errorf("parseFileLocation(%q) = %q, %q, %q, %v", tt.loc, path, start, end, err)
+		}
+	}
+}
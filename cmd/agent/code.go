@@ -0,0 +1,703 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	ctx "github.com/esifea/ai-driven-automation/internal/context"
+	"github.com/esifea/ai-driven-automation/internal/parser"
+	"github.com/esifea/ai-driven-automation/internal/provider"
+	"github.com/esifea/ai-driven-automation/internal/role"
+	"github.com/esifea/ai-driven-automation/internal/run"
+)
+
+func runCode(cfg *config.Config, args []string) error {
+	fs := newFlagSet("code", "--task ID [flags]",
+		"Implement a task: analyze which files are needed, generate the\nimplementation and write the files to the working tree.")
+	fs.StringVar(&cfg.TaskID, "task", cfg.TaskID, "Task ID (env TASK_ID)")
+	fs.StringVar(&cfg.Feedback, "feedback", cfg.Feedback, "Reviewer feedback to address (env FEEDBACK)")
+	fs.BoolVar(&cfg.DryRun, "dry-run", cfg.DryRun, "Print a unified diff instead of writing files (env DRY_RUN)")
+	fs.StringVar(&cfg.PatchFile, "patch", cfg.PatchFile, "With --dry-run, also save the diff to this file for git apply (env PATCH_FILE)")
+	addCoderFlags(fs, cfg)
+	addProviderFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := requireFlags(fs, map[string]string{"task": cfg.TaskID}); err != nil {
+		return err
+	}
+
+	llm, err := setup(cfg, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = runCoderMode(llm, cfg)
+	return err
+}
+
+// maxEditRetries bounds the re-prompts for edit blocks that failed to apply
+const maxEditRetries = 2
+
+// maxRepairRounds bounds the re-prompts for files with syntax errors
+const maxRepairRounds = 2
+
+// addCoderFlags binds flags shared by commands that run coder mode
+func addCoderFlags(fs *flag.FlagSet, cfg *config.Config) {
+	fs.StringVar(&cfg.EditFormat, "edit-format", cfg.EditFormat, "Coder output: whole, search-replace or diff (env EDIT_FORMAT)")
+	fs.StringVar(&cfg.ProtectedPaths, "protected", cfg.ProtectedPaths, "Comma-separated paths the coder may not write, at any depth unless they start with / (env PROTECTED_PATHS)")
+	fs.StringVar(&cfg.ScopePolicy, "scope", cfg.ScopePolicy, "Files outside TARGET FILES and the analysis: error, warn or note (env SCOPE_POLICY)")
+	fs.Float64Var(&cfg.MaxShrink, "max-shrink", cfg.MaxShrink, "Share of lines a file may lose before it is re-prompted as elided, 0 disables (env MAX_SHRINK)")
+	fs.StringVar(&cfg.SyntaxChecks, "syntax-checks", cfg.SyntaxChecks, "Extra syntax checkers, e.g. \".py=python3 -m py_compile;.sh=bash -n\" (env SYNTAX_CHECKS)")
+}
+
+// newSandbox confines coder reads and writes to the working directory
+func newSandbox(cfg *config.Config) (*parser.Sandbox, error) {
+	var protected []string
+	for _, p := range strings.Split(cfg.ProtectedPaths, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			protected = append(protected, p)
+		}
+	}
+	return parser.NewSandbox(".", protected)
+}
+
+// newValidator checks Go and JSON, plus the configured ".ext=command" checkers.
+// An empty command disables the checker of that extension.
+func newValidator(cfg *config.Config) (*parser.Validator, error) {
+	v := parser.NewValidator()
+	for _, entry := range strings.Split(cfg.SyntaxChecks, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		ext, command, ok := strings.Cut(entry, "=")
+		ext, command = strings.TrimSpace(ext), strings.TrimSpace(command)
+		if !ok || ext == "" {
+			return nil, fmt.Errorf("invalid syntax check %q, want .ext=command", entry)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		if command == "" {
+			v.Register(ext, nil)
+		} else {
+			v.Register(ext, parser.CommandChecker(command))
+		}
+	}
+	return v, nil
+}
+
+// coderInput holds the context shared by the analysis and implementation passes
+type coderInput struct {
+	overview         string
+	instruction      string
+	metadata         *ctx.TaskMetadata
+	codebase         *ctx.ContextType
+	dependentContext string
+	analysisContext  string
+}
+
+func loadCoderInput(taskID string) (*coderInput, error) {
+	// Load context
+	overview := ctx.GetOverviewDoc()
+	instruction, err := ctx.GetInstructionDoc(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task instructions: %w", err)
+	}
+
+	// Parse task metadata (TARGET FILES, DEPENDS_ON)
+	taskMetadata := ctx.ParseTaskMetadata(instruction)
+	slog.Info("Target files from task", "files", taskMetadata.TargetFiles)
+
+	var dependentContext string
+
+	if len(taskMetadata.DependsOn) > 0 {
+		slog.Info("Task dependencies", "depends_on", taskMetadata.DependsOn)
+		dependentContext = ctx.GetDependentContext(taskID, taskMetadata.DependsOn)
+	}
+
+	// Build initial context (targets full, others signatures)
+	codebaseCtx := ctx.GetCodebaseContext(taskMetadata)
+	slog.Info("Loaded codebase context",
+		"target_files", len(codebaseCtx.TargetFiles), "signature_files", len(codebaseCtx.SignatureFiles))
+
+	analysisContext := codebaseCtx.GetContextForAnalysis()
+	if dependentContext != "" {
+		analysisContext = dependentContext + "\n\n" + analysisContext
+	}
+
+	return &coderInput{
+		overview:         overview,
+		instruction:      instruction,
+		metadata:         taskMetadata,
+		codebase:         codebaseCtx,
+		dependentContext: dependentContext,
+		analysisContext:  analysisContext,
+	}, nil
+}
+
+// runAnalysisPass asks which files are needed and loads them into the codebase context
+func runAnalysisPass(llm provider.Provider, in *coderInput) (*role.AnalysisResult, error) {
+	slog.Info("Pass 1: analyzing task and identifying files")
+
+	analysis, err := role.RunAnalysis(
+		rootCtx, llm,
+		&role.AnalysisRequest{
+			Mode:        role.AnalysisModeCoder,
+			Instruction: in.instruction,
+			Context:     in.analysisContext,
+			Overview:    in.overview,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	applyAnalysis(in, analysis)
+	return analysis, nil
+}
+
+func applyAnalysis(in *coderInput, analysis *role.AnalysisResult) {
+	runReport.Analysis = analysis
+
+	additionalPaths := analysis.GetAdditionalFilePaths()
+	if len(additionalPaths) > 0 {
+		slog.Info("Pass 1 identified additional files", "files", additionalPaths)
+		in.codebase.ReloadFiles(additionalPaths)
+	}
+}
+
+// coderResult describes what one coder run produced
+type coderResult struct {
+	files   map[string]string // Parsed files
+	deletes []string          // Deleted files, including rename sources
+	renames parser.Renames    // New path of each renamed file to its source
+	changes []parser.Change   // What was written, empty for a dry run
+	patch   string            // Diff against the working tree before writing
+}
+
+func runCoderMode(llm provider.Provider, cfg *config.Config) (*coderResult, error) {
+	r, err := openRun("")
+	if err != nil {
+		return nil, err
+	}
+
+	return startCoderRun(llm, cfg, r)
+}
+
+// startCoderRun runs coder mode from the start, checkpointing into r
+func startCoderRun(llm provider.Provider, cfg *config.Config, r *run.Run) (*coderResult, error) {
+	switch cfg.EditFormat {
+	case parser.FormatWhole, parser.FormatSearchReplace, parser.FormatDiff:
+	default:
+		return nil, fmt.Errorf("unknown edit format %q", cfg.EditFormat)
+	}
+	switch cfg.ScopePolicy {
+	case parser.ScopeError, parser.ScopeWarn, parser.ScopeNote:
+	default:
+		return nil, fmt.Errorf("unknown scope policy %q", cfg.ScopePolicy)
+	}
+
+	state := &run.State{
+		Mode:       runReport.Mode,
+		TaskID:     cfg.TaskID,
+		Feedback:   cfg.Feedback,
+		DryRun:     cfg.DryRun,
+		PatchFile:  cfg.PatchFile,
+		EditFormat: cfg.EditFormat,
+		Guards: &run.Guards{
+			ProtectedPaths: cfg.ProtectedPaths,
+			ScopePolicy:    cfg.ScopePolicy,
+			SyntaxChecks:   cfg.SyntaxChecks,
+			MaxShrink:      cfg.MaxShrink,
+		},
+		Stage: run.StageStarted,
+	}
+	if err := r.SaveState(state); err != nil {
+		return nil, err
+	}
+
+	return runCoderStages(llm, cfg, r, state)
+}
+
+// runCoderStages continues a coder run after its last checkpointed stage
+func runCoderStages(llm provider.Provider, cfg *config.Config, r *run.Run, state *run.State) (*coderResult, error) {
+	slog.Info("Coder agent started", "stage", state.Stage)
+
+	if cfg.Feedback != "" {
+		slog.Info("Feedback received", "feedback", cfg.Feedback)
+	}
+
+	checkpoint := func(stage run.Stage) error {
+		state.Stage = stage
+		runReport.Stage = string(stage)
+		return r.SaveState(state)
+	}
+	runReport.Stage = string(state.Stage)
+
+	result := &coderResult{}
+	if state.Stage.Reached(run.StageFiles) {
+		if err := r.ReadJSON(run.FilesFile, &result.files); err != nil {
+			return nil, err
+		}
+		if r.Exists(run.DeletesFile) {
+			if err := r.ReadJSON(run.DeletesFile, &result.deletes); err != nil {
+				return nil, err
+			}
+		}
+		if r.Exists(run.RenamesFile) {
+			if err := r.ReadJSON(run.RenamesFile, &result.renames); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		var generated string
+		if state.Stage.Reached(run.StageResponse) {
+			slog.Info("Re-parsing saved coder response")
+			data, err := r.ReadFile(run.ResponseFile)
+			if err != nil {
+				return nil, err
+			}
+			generated = string(data)
+		} else {
+			var err error
+			generated, err = generateImplementation(llm, cfg, r, state, checkpoint)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		result, err = resolveCoderOutput(llm, cfg, r, generated)
+		if err != nil {
+			return nil, err
+		}
+		if err := repairElisions(llm, cfg, r, result.files); err != nil {
+			return nil, err
+		}
+		if err := repairSyntax(llm, cfg, r, result.files); err != nil {
+			return nil, err
+		}
+	}
+
+	if !state.Stage.Reached(run.StageFiles) {
+		if err := r.WriteJSON(run.FilesFile, result.files); err != nil {
+			return nil, err
+		}
+		if len(result.deletes) > 0 {
+			if err := r.WriteJSON(run.DeletesFile, result.deletes); err != nil {
+				return nil, err
+			}
+		}
+		if len(result.renames) > 0 {
+			if err := r.WriteJSON(run.RenamesFile, result.renames); err != nil {
+				return nil, err
+			}
+		}
+		if err := checkpoint(run.StageFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(result.files) == 0 && len(result.deletes) == 0 {
+		slog.Warn("Coder generated no file output")
+		return result, checkpoint(run.StageDone)
+	}
+
+	sandbox, err := newSandbox(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if rejections := sandbox.Check(append(sortedKeys(result.files), result.deletes...)); len(rejections) > 0 {
+		return nil, rejectPaths(rejections)
+	}
+
+	if err := enforceScope(cfg, r, result); err != nil {
+		return nil, err
+	}
+	if len(result.files) == 0 && len(result.deletes) == 0 {
+		slog.Warn("No file output left in scope")
+		return result, checkpoint(run.StageDone)
+	}
+
+	// Final newline, line endings, BOM and indentation of the files being
+	// replaced, or renamed from
+	if err := parser.KeepConventions(sandbox, result.files, result.renames); err != nil {
+		return nil, err
+	}
+
+	result.patch, err = parser.DiffFiles(sandbox, result.files, result.renames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff files: %w", err)
+	}
+	deletePatch, err := parser.DiffDeletes(sandbox, result.deletes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff files: %w", err)
+	}
+	result.patch += deletePatch
+
+	if cfg.DryRun {
+		if err := printDryRun(result, cfg.PatchFile); err != nil {
+			return nil, err
+		}
+		return result, checkpoint(run.StageDone)
+	}
+
+	// All or nothing: a failure restores every file already replaced
+	result.changes, err = parser.WriteFiles(rootCtx, sandbox, result.files, result.deletes, result.renames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write files: %w", err)
+	}
+	slog.Info("Coder wrote files to disk", "changed", len(result.changes))
+	recordChanges(result.changes)
+
+	return result, checkpoint(run.StageDone)
+}
+
+// generateImplementation runs both passes, reusing a saved analysis
+func generateImplementation(llm provider.Provider, cfg *config.Config, r *run.Run, state *run.State, checkpoint func(run.Stage) error) (string, error) {
+	in, err := loadCoderInput(cfg.TaskID)
+	if err != nil {
+		return "", err
+	}
+
+	// === Pass 1: Analysis ===
+	if state.Stage.Reached(run.StageAnalysis) {
+		if r.Exists(run.AnalysisFile) {
+			slog.Info("Using saved analysis")
+			var analysis role.AnalysisResult
+			if err := r.ReadJSON(run.AnalysisFile, &analysis); err != nil {
+				return "", err
+			}
+			applyAnalysis(in, &analysis)
+		}
+	} else {
+		if analysis, err := runAnalysisPass(llm, in); err != nil {
+			slog.Warn("Analysis failed, proceeding with target files only", "error", err)
+		} else if err := r.WriteJSON(run.AnalysisFile, analysis); err != nil {
+			return "", err
+		}
+		if err := checkpoint(run.StageAnalysis); err != nil {
+			return "", err
+		}
+	}
+
+	// === Pass 2: Implementation ===
+	slog.Info("Pass 2: generating implementation")
+	generated, err := role.RunCoder(
+		rootCtx, llm, cfg,
+		in.instruction, in.codebase.GetContextForImplementation(), in.overview,
+	)
+	if err != nil {
+		return "", fmt.Errorf("code generation failed: %w", err)
+	}
+
+	if err := r.WriteFile(run.ResponseFile, []byte(generated)); err != nil {
+		return "", err
+	}
+	if err := checkpoint(run.StageResponse); err != nil {
+		return "", err
+	}
+
+	return generated, nil
+}
+
+// resolveCoderOutput turns the coder response into the full content of each file,
+// the files to delete and the renames among them
+func resolveCoderOutput(llm provider.Provider, cfg *config.Config, r *run.Run, generated string) (*coderResult, error) {
+	// Files are only read through the sandbox, so the model cannot pull
+	// content from outside the repository into a prompt
+	sandbox, err := newSandbox(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Directives apply first, so edits can target the new path of a rename
+	base, deletes, renames, err := parser.ApplyFileOps(sandbox, parser.ParseFileOps(generated), nil)
+	var rejected *parser.RejectedError
+	if errors.As(err, &rejected) {
+		return nil, rejectPaths(rejected.Rejections)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files map[string]string
+	var failures []parser.EditFailure
+
+	switch cfg.EditFormat {
+	case parser.FormatSearchReplace:
+		files, failures = parser.ApplyEditBlocks(sandbox, parser.ParseEditBlocks(generated), base)
+	case parser.FormatDiff:
+		// Failed hunks are retried as SEARCH/REPLACE blocks
+		files, failures = parser.ApplyPatches(sandbox, parser.ParsePatches(generated), base)
+	default:
+		parsed, err := sandbox.NormalizeFiles(parser.ParseFiles(generated))
+		if err != nil {
+			return nil, err
+		}
+		files = base
+		for path, content := range parsed {
+			files[path] = content
+		}
+	}
+
+	for round := 1; len(failures) > 0 && round <= maxEditRetries && llm != nil; round++ {
+		for _, f := range failures {
+			slog.Warn("Edit block failed to apply", "path", f.Block.Path, "reason", f.Reason)
+		}
+		slog.Info("Re-prompting for failed edit blocks", "count", len(failures), "round", round)
+
+		retry, err := role.RunEditRetry(rootCtx, llm, failures, parser.FailedFileContents(sandbox, files, failures))
+		if err != nil {
+			return nil, fmt.Errorf("edit retry failed: %w", err)
+		}
+		if err := r.WriteFile(fmt.Sprintf("coder_retry_%d.md", round), []byte(retry)); err != nil {
+			return nil, err
+		}
+
+		files, failures = parser.ApplyEditBlocks(sandbox, parser.ParseEditBlocks(retry), files)
+	}
+
+	if len(failures) > 0 {
+		runReport.EditFailures = nil
+		for _, f := range failures {
+			slog.Error("Edit block failed to apply", "path", f.Block.Path, "reason", f.Reason)
+			runReport.EditFailures = append(runReport.EditFailures, f.Error())
+		}
+		return nil, fmt.Errorf("%d edit block(s) failed to apply", len(failures))
+	}
+
+	// A deleted path written again is an overwrite
+	kept := deletes[:0]
+	for _, path := range deletes {
+		if _, ok := files[path]; !ok {
+			kept = append(kept, path)
+		}
+	}
+
+	return &coderResult{files: files, deletes: kept, renames: renames}, nil
+}
+
+// repairElisions asks the coder for the complete content of files that contain
+// placeholders or shrink past cfg.MaxShrink, replacing their content in place
+func repairElisions(llm provider.Provider, cfg *config.Config, r *run.Run, files map[string]string) error {
+	sandbox, err := newSandbox(cfg)
+	if err != nil {
+		return err
+	}
+
+	elisions, err := parser.CheckElisions(sandbox, files, cfg.MaxShrink)
+	if err != nil {
+		return err
+	}
+
+	for round := 1; len(elisions) > 0 && round <= maxRepairRounds && llm != nil; round++ {
+		originals := make(map[string]string, len(elisions))
+		for _, e := range elisions {
+			slog.Warn("Generated file looks elided", "path", e.Path, "reason", e.Reason)
+			if original, exists, err := sandbox.ReadFile(e.Path); err == nil && exists {
+				originals[e.Path] = original
+			}
+		}
+		slog.Info("Re-prompting for complete file content", "count", len(elisions), "round", round)
+
+		repair, err := role.RunElisionRepair(rootCtx, llm, elisions, files, originals)
+		if err != nil {
+			return fmt.Errorf("elision repair failed: %w", err)
+		}
+		if err := r.WriteFile(fmt.Sprintf("coder_elision_%d.md", round), []byte(repair)); err != nil {
+			return err
+		}
+
+		// Only the flagged files may be replaced
+		repaired, err := sandbox.NormalizeFiles(parser.ParseFiles(repair))
+		if err != nil {
+			return err
+		}
+		for _, e := range elisions {
+			if content, ok := repaired[e.Path]; ok {
+				files[e.Path] = content
+			}
+		}
+		if elisions, err = parser.CheckElisions(sandbox, files, cfg.MaxShrink); err != nil {
+			return err
+		}
+	}
+
+	if len(elisions) > 0 {
+		runReport.Elisions = nil
+		for _, e := range elisions {
+			slog.Error("Generated file looks elided", "path", e.Path, "reason", e.Reason)
+			runReport.Elisions = append(runReport.Elisions, e.Error())
+		}
+		return fmt.Errorf("%d file(s) look elided", len(elisions))
+	}
+
+	return nil
+}
+
+// repairSyntax validates files and asks the coder to fix the ones that fail,
+// replacing their content in place
+func repairSyntax(llm provider.Provider, cfg *config.Config, r *run.Run, files map[string]string) error {
+	validator, err := newValidator(cfg)
+	if err != nil {
+		return err
+	}
+	sandbox, err := newSandbox(cfg)
+	if err != nil {
+		return err
+	}
+	errs := validator.Validate(files)
+
+	for round := 1; len(errs) > 0 && round <= maxRepairRounds && llm != nil; round++ {
+		for _, e := range errs {
+			slog.Warn("Syntax error in generated file", "path", e.Path, "error", e.Message)
+		}
+		slog.Info("Re-prompting to repair syntax errors", "count", len(errs), "round", round)
+
+		repair, err := role.RunSyntaxRepair(rootCtx, llm, errs, files)
+		if err != nil {
+			return fmt.Errorf("syntax repair failed: %w", err)
+		}
+		if err := r.WriteFile(fmt.Sprintf("coder_repair_%d.md", round), []byte(repair)); err != nil {
+			return err
+		}
+
+		// Only the broken files may be replaced
+		repaired, err := sandbox.NormalizeFiles(parser.ParseFiles(repair))
+		if err != nil {
+			return err
+		}
+		for _, e := range errs {
+			if content, ok := repaired[e.Path]; ok {
+				files[e.Path] = content
+			}
+		}
+		errs = validator.Validate(files)
+	}
+
+	if len(errs) > 0 {
+		runReport.SyntaxErrors = nil
+		for _, e := range errs {
+			slog.Error("Syntax error in generated file", "path", e.Path, "error", e.Message)
+			runReport.SyntaxErrors = append(runReport.SyntaxErrors, e.Error())
+		}
+		return fmt.Errorf("%d file(s) with syntax errors", len(errs))
+	}
+
+	return nil
+}
+
+// taskScope allows the task's TARGET FILES and the files the analysis pass
+// planned to modify or create
+func taskScope(cfg *config.Config, r *run.Run) (*parser.Scope, error) {
+	instruction, err := ctx.GetInstructionDoc(cfg.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task instructions: %w", err)
+	}
+	paths := ctx.ParseTaskMetadata(instruction).TargetFiles
+
+	analysis := runReport.Analysis
+	if analysis == nil && r.Exists(run.AnalysisFile) {
+		analysis = &role.AnalysisResult{}
+		if err := r.ReadJSON(run.AnalysisFile, analysis); err != nil {
+			return nil, err
+		}
+	}
+	if analysis != nil {
+		for _, f := range append(analysis.FilesToModify, analysis.FilesToCreate...) {
+			paths = append(paths, f.Path)
+		}
+	}
+
+	return parser.NewScope(paths), nil
+}
+
+// enforceScope applies the scope policy to files outside the task scope. With
+// "note" they are left out of result and saved under out_of_scope/ in the run.
+func enforceScope(cfg *config.Config, r *run.Run, result *coderResult) error {
+	scope, err := taskScope(cfg, r)
+	if err != nil {
+		return err
+	}
+	if scope.Empty() {
+		slog.Warn("Task has no TARGET FILES or analysis, scope not enforced")
+		return nil
+	}
+
+	outside := scope.OutOfScope(append(sortedKeys(result.files), result.deletes...))
+	if len(outside) == 0 {
+		return nil
+	}
+	runReport.OutOfScope, runReport.ScopePolicy = outside, cfg.ScopePolicy
+
+	switch cfg.ScopePolicy {
+	case parser.ScopeError:
+		for _, path := range outside {
+			slog.Error("File outside the task scope", "path", path)
+		}
+		return fmt.Errorf("%d file(s) outside the task scope", len(outside))
+	case parser.ScopeWarn:
+		for _, path := range outside {
+			slog.Warn("Writing file outside the task scope", "path", path)
+		}
+	case parser.ScopeNote:
+		// A rename is left out whole, or its source would be deleted with nothing in its place
+		for _, path := range result.renames.WithPartners(outside) {
+			if slices.Contains(outside, path) {
+				slog.Warn("Leaving out file outside the task scope", "path", path)
+			} else {
+				slog.Warn("Leaving out rename of a file outside the task scope", "path", path)
+			}
+			if content, ok := result.files[path]; ok {
+				if err := r.WriteFile(filepath.Join("out_of_scope", path), []byte(content)); err != nil {
+					return err
+				}
+				delete(result.files, path)
+			}
+			result.deletes = slices.DeleteFunc(result.deletes, func(p string) bool { return p == path })
+		}
+	}
+
+	return nil
+}
+
+// rejectPaths records paths the sandbox refused and fails the run
+func rejectPaths(rejections []parser.Rejection) error {
+	runReport.RejectedPaths = nil
+	for _, rej := range rejections {
+		slog.Error("Rejected file path", "path", rej.Path, "reason", rej.Reason)
+		runReport.RejectedPaths = append(runReport.RejectedPaths, rej.Error())
+	}
+	return fmt.Errorf("%d file path(s) rejected", len(rejections))
+}
+
+// printDryRun shows what the coder would change without touching the working tree
+func printDryRun(result *coderResult, patchFile string) error {
+	if result.patch == "" {
+		slog.Info("Dry run: generated files match the working tree")
+		return nil
+	}
+
+	fmt.Print(result.patch)
+
+	if patchFile != "" {
+		if err := os.WriteFile(patchFile, []byte(result.patch), 0644); err != nil {
+			return fmt.Errorf("failed to write patch: %w", err)
+		}
+		slog.Info("Dry run: patch saved, apply with git apply", "path", patchFile)
+	}
+
+	slog.Info("Dry run: files not written", "files", len(result.files), "deletes", len(result.deletes))
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/esifea/ai-driven-automation/internal/parser"
+	"github.com/esifea/ai-driven-automation/internal/run"
+)
+
+func TestEnforceScope(t *testing.T) {
+	tests := []struct {
+		policy  string
+		err     bool
+		files   []string
+		deletes []string
+		noted   []string
+	}{
+		{policy: parser.ScopeError, err: true},
+		{
+			policy:  parser.ScopeWarn,
+			files:   []string{"in/a.go", "out/b.go", "out/moved.go"},
+			deletes: []string{"in/old.go"},
+		},
+		// The rename of in/old.go is left out whole, so its source is not deleted
+		{
+			policy: parser.ScopeNote,
+			files:  []string{"in/a.go"},
+			noted:  []string{"out/b.go", "out/moved.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			cfg := testRepo(t, map[string]string{
+				"docs/tasks/01_task.md": "# Task\n\nTARGET FILES:\n- in/\n",
+			})
+			cfg.ScopePolicy = tt.policy
+			r, err := run.New(filepath.Join(t.TempDir(), "runs"), "scope")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := &coderResult{
+				files:   map[string]string{"in/a.go": "a", "out/b.go": "b", "out/moved.go": "moved"},
+				deletes: []string{"in/old.go"},
+				renames: parser.Renames{"out/moved.go": "in/old.go"},
+			}
+
+			err = enforceScope(cfg, r, result)
+			if !reflect.DeepEqual(runReport.OutOfScope, []string{"out/b.go", "out/moved.go"}) {
+				t.Errorf("reported out of scope = %v", runReport.OutOfScope)
+			}
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := slices.Sorted(maps.Keys(result.files)); !reflect.DeepEqual(got, tt.files) {
+				t.Errorf("files = %v, want %v", got, tt.files)
+			}
+			if !reflect.DeepEqual(result.deletes, tt.deletes) && len(result.deletes)+len(tt.deletes) > 0 {
+				t.Errorf("deletes = %v, want %v", result.deletes, tt.deletes)
+			}
+			for _, path := range tt.noted {
+				if !r.Exists(filepath.Join("out_of_scope", path)) {
+					t.Errorf("%s should be saved under out_of_scope/", path)
+				}
+			}
+		})
+	}
+}
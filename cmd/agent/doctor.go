@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	ctx "github.com/esifea/ai-driven-automation/internal/context"
+)
+
+type checkResult struct {
+	name   string
+	status string // ok, warn, fail
+	detail string
+}
+
+func runDoctor(cfg *config.Config, args []string) error {
+	fs := newFlagSet("doctor", "[--task ID] [flags]",
+		"Check credentials, the task directory and required tools without\ncalling any provider.")
+	fs.StringVar(&cfg.TaskID, "task", cfg.TaskID, "Also check that this task loads (env TASK_ID)")
+	addProviderFlags(fs, cfg)
+	addReviewerFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var results []checkResult
+	add := func(name, status, detail string) {
+		results = append(results, checkResult{name, status, detail})
+	}
+
+	// Credentials
+	if creds, err := cfg.CoderCredentials(); err != nil {
+		add("coder provider", "fail", err.Error())
+	} else {
+		add("coder provider", "ok", describeCredentials(creds))
+	}
+
+	if creds, err := cfg.ReviewerCredentials(); err != nil {
+		add("reviewer provider", "fail", err.Error())
+	} else {
+		add("reviewer provider", "ok", describeCredentials(creds))
+	}
+
+	// Task store
+	if err := initTaskStore(cfg); err != nil {
+		add("task store", "fail", err.Error())
+	} else {
+		store := ctx.GetTaskStore()
+		if tasks, err := store.Tasks(); err != nil {
+			add("task store", "fail", err.Error())
+		} else {
+			add("task store", "ok", fmt.Sprintf("%s (%d tasks)", cfg.TasksDir, len(tasks)))
+		}
+
+		if _, err := os.Stat(store.OverviewPath()); err != nil {
+			add("overview", "warn", fmt.Sprintf("%s not found", store.OverviewPath()))
+		} else {
+			add("overview", "ok", store.OverviewPath())
+		}
+
+		if cfg.TaskID != "" {
+			if _, err := store.Instruction(cfg.TaskID); err != nil {
+				add("task "+cfg.TaskID, "fail", err.Error())
+			} else {
+				add("task "+cfg.TaskID, "ok", "instructions found")
+			}
+		}
+	}
+
+	// Tools
+	if err := exec.Command("git", "rev-parse", "--is-inside-work-tree").Run(); err != nil {
+		add("git", "fail", "not inside a git work tree")
+	} else {
+		add("git", "ok", "inside a git work tree")
+	}
+
+	if _, err := exec.LookPath("gh"); err != nil {
+		add("gh", "warn", "gh CLI not found, review submission will fail")
+	} else {
+		add("gh", "ok", "found")
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.status == "fail" {
+			failed++
+		}
+		fmt.Printf("[%-4s] %-18s %s\n", r.status, r.name, r.detail)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+func describeCredentials(creds *config.Credentials) string {
+	var parts []string
+	parts = append(parts, creds.Provider)
+	if creds.Model != "" {
+		parts = append(parts, "model="+creds.Model)
+	}
+	if creds.BaseURL != "" {
+		parts = append(parts, "base_url="+creds.BaseURL)
+	}
+	if creds.APIKey != "" {
+		parts = append(parts, "key=set")
+	}
+	return strings.Join(parts, " ")
+}
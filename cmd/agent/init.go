@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	ctx "github.com/esifea/ai-driven-automation/internal/context"
+	"github.com/esifea/ai-driven-automation/internal/prompt"
+)
+
+const overviewTemplate = `# Project Overview
+
+Global rules applied to every task. Describe the project so the agent can
+follow its conventions.
+
+## Architecture
+- Main packages/modules and what they are responsible for
+
+## Conventions
+- Naming, error handling and logging patterns to follow
+- Where tests live and how they are written
+
+## Constraints
+- Files or directories the agent must not touch
+- Dependencies that must not be added
+`
+
+const sampleTaskTemplate = `# Task 01: Example Task
+
+LANGUAGE: %s
+TARGET FILES:
+- %s # new
+
+## Objective
+Describe what needs to be implemented.
+
+## Requirements
+- Specific requirement 1
+- Specific requirement 2
+
+## Files to Create/Modify
+- ` + "`%s`" + ` - Description
+
+## Acceptance Criteria
+- [ ] Criterion 1
+- [ ] Criterion 2
+`
+
+// Example target file of the sample task per language
+var sampleTargetFiles = map[prompt.Language]string{
+	prompt.LangGo:     "internal/example/example.go",
+	prompt.LangPython: "example/example.py",
+	prompt.LangCpp:    "src/example.cpp",
+}
+
+func runInit(cfg *config.Config, args []string) error {
+	fs := newFlagSet("init", "[--language go|python|cpp] [--force] [flags]",
+		"Scaffold the task directory: an overview skeleton, the built-in language\nstandards for customizing, and a sample task. Existing files are kept\nunless --force is given.")
+	language := fs.String("language", "", "Project language, detected from the repository when empty")
+	force := fs.Bool("force", false, "Overwrite existing files")
+	fs.StringVar(&cfg.TasksDir, "tasks-dir", cfg.TasksDir, "Task documents directory (env TASKS_DIR)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *language != "" && prompt.ParseLanguage(*language) == prompt.LangUnknown {
+		return fmt.Errorf("unsupported language %q (want go, python or cpp)", *language)
+	}
+
+	lang := prompt.DetectLanguage(*language, "", nil, ctx.ListCodeFiles("."))
+	slog.Info("Detected language", "language", lang)
+
+	target := sampleTargetFiles[lang]
+	files := []struct {
+		path    string
+		content string
+	}{
+		{filepath.Join(cfg.TasksDir, cfg.TasksOverview), overviewTemplate},
+		{filepath.Join(cfg.TasksDir, "languages", string(lang)+".md"), prompt.BuiltinLanguagePrompt(lang).Markdown()},
+		{filepath.Join(cfg.TasksDir, "01_example_task.md"), fmt.Sprintf(sampleTaskTemplate, lang, target, target)},
+	}
+
+	for _, f := range files {
+		if !*force {
+			if _, err := os.Stat(f.path); err == nil {
+				slog.Info("Skipping existing file, use --force to overwrite", "path", f.path)
+				continue
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to check %s: %w", f.path, err)
+			}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", f.path, err)
+		}
+		if err := os.WriteFile(f.path, []byte(f.content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.path, err)
+		}
+		fmt.Println(f.path)
+	}
+
+	return nil
+}
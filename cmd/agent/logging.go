@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+)
+
+var (
+	// Shared by all handlers so --log-level applies after setup
+	logLevel = new(slog.LevelVar)
+
+	// Source of the task ID attached to every record
+	logCfg *config.Config
+)
+
+// runHandler adds the run ID, mode and task ID to every record
+type runHandler struct {
+	slog.Handler
+}
+
+func (h runHandler) Handle(c context.Context, r slog.Record) error {
+	taskID := ""
+	if logCfg != nil {
+		taskID = logCfg.TaskID
+	}
+	r.AddAttrs(
+		slog.String("run_id", runReport.RunID),
+		slog.String("mode", runReport.Mode),
+		slog.String("task_id", taskID),
+	)
+	return h.Handler.Handle(c, r)
+}
+
+func (h runHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return runHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h runHandler) WithGroup(name string) slog.Handler {
+	return runHandler{h.Handler.WithGroup(name)}
+}
+
+// initLogging sends diagnostics to stderr, stdout is reserved for command output
+func initLogging(cfg *config.Config) error {
+	logCfg = cfg
+	if err := setLogLevel(cfg.LogLevel); err != nil {
+		return err
+	}
+	return setLogFormat(cfg.LogFormat)
+}
+
+func addLogFlags(fs *flag.FlagSet) {
+	fs.Func("log-level", "Log `level`: debug, info, warn, error (env LOG_LEVEL)", setLogLevel)
+	fs.Func("log-format", "Log `format`: text or json (env LOG_FORMAT)", setLogFormat)
+}
+
+func setLogLevel(level string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q (want debug, info, warn or error)", level)
+	}
+	logLevel.Set(l)
+	return nil
+}
+
+func setLogFormat(format string) error {
+	opts := &slog.HandlerOptions{Level: logLevel}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		h = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("invalid log format %q (want text or json)", format)
+	}
+
+	slog.SetDefault(slog.New(runHandler{h}))
+	return nil
+}
+
+// fatal logs the error and exits with a non-zero status
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	"github.com/esifea/ai-driven-automation/internal/provider"
+	"github.com/esifea/ai-driven-automation/internal/run"
+)
+
+type loopIteration struct {
+	Iteration    int      `json:"iteration"`
+	FilesWritten []string `json:"files_written"`
+	Verdict      string   `json:"verdict"`
+}
+
+type loopSummary struct {
+	RunID      string          `json:"run_id"`
+	TaskID     string          `json:"task_id"`
+	Verdict    string          `json:"verdict"`
+	Iterations []loopIteration `json:"iterations"`
+}
+
+func runLoop(cfg *config.Config, args []string) error {
+	fs := newFlagSet("loop", "--task ID [--max-iterations N] [flags]",
+		"Run the coder, review the result and feed the critique back to the coder\nuntil the reviewer returns STATUS: PASS or the iteration cap is reached.\nEach iteration's checkpoints, diff and verdict are saved under iter-NN/\nin the run directory.")
+	fs.StringVar(&cfg.TaskID, "task", cfg.TaskID, "Task ID (env TASK_ID)")
+	fs.StringVar(&cfg.Feedback, "feedback", cfg.Feedback, "Initial feedback for the first iteration (env FEEDBACK)")
+	maxIterations := fs.Int("max-iterations", 3, "Maximum coder/reviewer iterations")
+	runRoot := fs.String("run-dir", run.DefaultRoot, "Directory to store run artifacts in")
+	addCoderFlags(fs, cfg)
+	addProviderFlags(fs, cfg)
+	addReviewerFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := requireFlags(fs, map[string]string{"task": cfg.TaskID}); err != nil {
+		return err
+	}
+	if *maxIterations < 1 {
+		return fmt.Errorf("--max-iterations must be at least 1")
+	}
+
+	coder, err := setup(cfg, false)
+	if err != nil {
+		return err
+	}
+	reviewer, err := newProvider(cfg, true)
+	if err != nil {
+		return err
+	}
+
+	r, err := openRun(*runRoot)
+	if err != nil {
+		return err
+	}
+	slog.Info("Saving run artifacts", "dir", r.Dir)
+
+	// The loop always writes files so the reviewer sees them
+	cfg.DryRun = false
+
+	summary, err := loop(coder, reviewer, cfg, r, *maxIterations)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Task %s: %s after %d iteration(s). Run: %s\n",
+		cfg.TaskID, summary.Verdict, len(summary.Iterations), r.Dir)
+
+	if summary.Verdict != verdictPass {
+		return fmt.Errorf("review did not pass after %d iterations", *maxIterations)
+	}
+	return nil
+}
+
+// loop runs coder and reviewer iterations until the review passes or
+// maxIterations is reached, and saves the summary in the run
+func loop(coder, reviewer provider.Provider, cfg *config.Config, r *run.Run, maxIterations int) (*loopSummary, error) {
+	summary := &loopSummary{RunID: r.ID, TaskID: cfg.TaskID, Verdict: verdictFail}
+
+	for i := 1; i <= maxIterations; i++ {
+		slog.Info("Starting iteration", "iteration", i, "max", maxIterations)
+
+		// Each iteration checkpoints on its own, so it can be inspected and resumed
+		iter, err := r.Sub(fmt.Sprintf("iter-%02d", i))
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := startCoderRun(coder, cfg, iter)
+		if err != nil {
+			return nil, fmt.Errorf("iteration %d (resume with %s): %w", i, iter.ID, err)
+		}
+
+		if err := iter.WriteFile("diff.patch", []byte(result.patch)); err != nil {
+			return nil, err
+		}
+
+		review, err := generateReview(reviewer, cfg.TaskID)
+		if err != nil {
+			return nil, fmt.Errorf("iteration %d: %w", i, err)
+		}
+
+		verdict := reviewVerdict(review)
+		slog.Info("Iteration finished", "iteration", i, "verdict", verdict)
+
+		if err := iter.WriteFile("review.md", []byte(review)); err != nil {
+			return nil, err
+		}
+		if err := iter.WriteFile("verdict", []byte(verdict+"\n")); err != nil {
+			return nil, err
+		}
+
+		summary.Iterations = append(summary.Iterations, loopIteration{
+			Iteration:    i,
+			FilesWritten: changedPaths(result.changes),
+			Verdict:      verdict,
+		})
+		summary.Verdict = verdict
+		runReport.Verdict, runReport.Review = verdict, review
+
+		if verdict == verdictPass {
+			break
+		}
+
+		// Feed the critique back to the next coder pass
+		cfg.Feedback = review
+	}
+
+	if err := r.WriteJSON("loop.json", summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
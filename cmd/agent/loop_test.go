@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/esifea/ai-driven-automation/internal/run"
+)
+
+func TestLoop(t *testing.T) {
+	tests := []struct {
+		name       string
+		reviews    []string
+		max        int
+		verdict    string
+		iterations int
+	}{
+		{"pass first", []string{"STATUS: PASS"}, 3, verdictPass, 1},
+		{"pass after feedback", []string{"STATUS: FAIL\n- fix it", "STATUS: PASS"}, 3, verdictPass, 2},
+		{"iteration cap", []string{"STATUS: FAIL", "STATUS: FAIL"}, 2, verdictFail, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testRepo(t, map[string]string{
+				"docs/tasks/01_task.md": "# Task\n\nTARGET FILES:\n- a.txt\n",
+			})
+
+			// Each iteration runs the analysis pass, then the implementation
+			var coder []string
+			for i := range tt.iterations {
+				coder = append(coder, "{}", fileResponse("a.txt", strings.Repeat("v\n", i+1)))
+			}
+			reviewer := &scriptedLLM{responses: tt.reviews}
+
+			r, err := run.New(filepath.Join(t.TempDir(), "runs"), "loop")
+			if err != nil {
+				t.Fatal(err)
+			}
+			summary, err := loop(&scriptedLLM{responses: coder}, reviewer, cfg, r, tt.max)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if summary.Verdict != tt.verdict || len(summary.Iterations) != tt.iterations {
+				t.Errorf("got %s after %d iteration(s), want %s after %d", summary.Verdict, len(summary.Iterations), tt.verdict, tt.iterations)
+			}
+			if reviewer.calls != tt.iterations {
+				t.Errorf("reviewer called %d times, want %d", reviewer.calls, tt.iterations)
+			}
+
+			// The critique of the last failed review is the next coder's feedback
+			if tt.iterations > 1 && cfg.Feedback != tt.reviews[tt.iterations-2] {
+				t.Errorf("feedback = %q", cfg.Feedback)
+			}
+			if !r.Exists("loop.json") {
+				t.Error("loop.json should be saved")
+			}
+			for i := 1; i <= tt.iterations; i++ {
+				state, err := mustOpen(t, r, i).LoadState()
+				if err != nil || state.Stage != run.StageDone {
+					t.Errorf("iteration %d state = %+v, %v", i, state, err)
+				}
+			}
+
+			data, _ := os.ReadFile("a.txt")
+			if string(data) != strings.Repeat("v\n", tt.iterations) {
+				t.Errorf("a.txt = %q", data)
+			}
+		})
+	}
+}
+
+func mustOpen(t *testing.T, r *run.Run, iteration int) *run.Run {
+	t.Helper()
+	iter, err := run.Open(r.Dir, fmt.Sprintf("iter-%02d", iteration))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return iter
+}
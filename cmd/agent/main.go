@@ -4,353 +4,289 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"os/exec"
+	"os/signal"
+	"sort"
 	"strings"
+	"syscall"
 
 	"github.com/esifea/ai-driven-automation/internal/config"
 	ctx "github.com/esifea/ai-driven-automation/internal/context"
-	"github.com/esifea/ai-driven-automation/internal/parser"
+	"github.com/esifea/ai-driven-automation/internal/prompt"
 	"github.com/esifea/ai-driven-automation/internal/provider"
-	"github.com/esifea/ai-driven-automation/internal/role"
 )
 
-func main() {
-	// Parse flags
-	mode := flag.String("mode", "", "Agent mode: coder or reviewer")
-	taskID := flag.String("task", "", "Task ID")
-	providerName := flag.String("provider", "", "LLM provider: claude, gemini")
-	flag.Parse()
-
-  fmt.Fprintf(os.Stderr, "DEBUG: flag mode=%q, flag task=%q\n", *mode, *taskID)
-
-	// Init config
-	cfg := config.Load()
-
-	// Override configs with flags if provided
-	if *mode != "" {
-		cfg.Mode = *mode
-	}
-	if *taskID != "" {
-		cfg.TaskID = *taskID
-	}
-	if *providerName != "" {
-		cfg.Provider = *providerName
-	}
-
-	llm, err := provider.NewProvider(cfg.Provider, cfg.APIKey, cfg.MaxRetries)
-	if err != nil {
-		log.Fatalf("Failed to initialize provider: %v", err)
-	}
-
-	// Handle Q&A mode
-	if cfg.PRQuestion != "" {
-		runQAMode(llm, cfg)
-		return
-	}
+type command struct {
+	name    string
+	summary string
+	run     func(cfg *config.Config, args []string) error
+}
 
-	// Handle modes
-	switch cfg.Mode {
-	case "reviewer":
-		runReviewerMode(llm, cfg)
-	case "summary":
-		runSummaryMode(llm, cfg)
-	default:
-		runCoderMode(llm, cfg)
-	}
+var commands = []*command{
+	{"code", "Implement a task (analysis + implementation passes)", runCode},
+	{"resume", "Continue a coder run from its last checkpoint", runResume},
+	{"review", "Review the working tree against a task and submit a PR review", runReview},
+	{"ask", "Answer a question about the current branch", runAsk},
+	{"chat", "Interactive Q&A session over the working tree", runChat},
+	{"summarize", "Generate a completion summary for a task", runSummarize},
+	{"init", "Scaffold the task directory for a new repository", runInit},
+	{"tasks", "List and inspect task documents", runTasks},
+	{"run-all", "Run coder mode on all pending tasks in dependency order", runRunAll},
+	{"loop", "Run coder and reviewer locally until the review passes", runLoop},
+	{"plan", "Run the analysis pass only and print the files it selects", runPlan},
+	{"prompt", "Print the prompts a mode would send, with token estimates", runPrompt},
+	{"doctor", "Check configuration, credentials and tooling", runDoctor},
 }
 
-func runQAMode(llm provider.Provider, cfg *config.Config) {
-	log.Println("--- Q&A MODE ---")
-	log.Println("Question:")
-	log.Printf("%s", cfg.PRQuestion)
-	log.Println("------------------")
+// rootCtx is canceled on SIGINT/SIGTERM so model calls and writes stop cleanly
+var rootCtx = context.Background()
+
+func main() {
+	args := os.Args[1:]
+
+	c, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	rootCtx = c
+	go func() {
+		// A second signal terminates immediately
+		<-c.Done()
+		stop()
+	}()
+
+	// Legacy invocation: agent --mode coder --task 01 (action.yml)
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if len(args) == 0 || isHelpFlag(args[0]) {
+			usage()
+			return
+		}
+		cfg := config.Load()
+		if err := initLogging(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
 
-	overview := ctx.GetOverviewDoc()
+		runReport.Mode = cfg.Mode
+		err := runLegacy(cfg, args)
+		if err == flag.ErrHelp {
+			return
+		}
 
-	// Get diff context (before/after changes)
-	diffCtx, err := ctx.GetDiffContext(cfg.BaseBranch)
-	if err != nil {
-		log.Printf("Warning: Could not get diff context: %v", err)
-		log.Println("Falling back to current codebase context only")
-		runQAFallback(llm, cfg, overview)
+		finishReport(cfg, err)
+		if err != nil {
+			fatal("agent failed", err)
+		}
 		return
 	}
 
-	log.Printf("Diff context: %d files changed (base: %s)", len(diffCtx.ChangedFiles), diffCtx.BaseBranch)
-
-	taskMetadata := &ctx.TaskMetadata{}
-	codebaseCtx := ctx.GetCodebaseContext(taskMetadata)
-
-	// Build analysis context: diff + signatures
-	analysisContext := diffCtx.GetContextForQA(cfg.CommentPath, cfg.CommentEndLine)
-	analysisContext += "\n\n=== OTHER FILES (signatures) ===\n"
-	for path, sig := range codebaseCtx.SignatureFiles {
-		// Skip files already in diff
-		if _, inDiff := diffCtx.FilesAfter[path]; inDiff {
-			continue
+	if args[0] == "help" {
+		if len(args) > 1 {
+			if cmd := findCommand(args[1]); cmd != nil {
+				cmd.run(config.Load(), []string{"-h"})
+				return
+			}
 		}
-		analysisContext += fmt.Sprintf("--- %s ---\n%s\n\n", path, sig)
+		usage()
+		return
 	}
 
-	// === Pass 1: Analyze what files are needed ===
-	log.Println("=== Q&A Pass 1: Analyzing question ===")
-
-	question := cfg.PRQuestion
-	if cfg.CommentPath != "" {
-		question = fmt.Sprintf("[File: %s, Line: %s] %s", cfg.CommentPath, cfg.CommentEndLine, question)
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		usage()
+		os.Exit(2)
 	}
 
-	analysis, err := role.RunAnalysis(
-		context.Background(), llm,
-		&role.AnalysisRequest{
-			Mode:        role.AnalysisModeQA,
-			Instruction: question,
-			Context:     analysisContext,
-			Overview:    overview,
-		},
-	)
-
-	var additionalFiles map[string]string
-	if err != nil {
-		log.Printf("Warning: Q&A analysis failed: %v", err)
-	} else {
-		additionalPaths := analysis.GetAdditionalFilePaths()
-		if len(additionalPaths) > 0 {
-			log.Printf("Pass 1 identified files needed: %v", additionalPaths)
-			additionalFiles = diffCtx.LoadAdditionalFiles(additionalPaths)
-		}
+	cfg := config.Load()
+	if err := initLogging(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
+	runReport.Mode = cmd.name
 
-	// Build signatures string (excluding diff and additional files)
-	var signaturesStr string
-	for path, sig := range codebaseCtx.SignatureFiles {
-		if _, inDiff := diffCtx.FilesAfter[path]; inDiff {
-			continue
-		}
-		if _, inAdditional := additionalFiles[path]; inAdditional {
-			continue
-		}
-		signaturesStr += fmt.Sprintf("--- %s ---\n%s\n\n", path, sig)
+	err := cmd.run(cfg, args[1:])
+	if err == flag.ErrHelp {
+		return
 	}
 
-	// === Pass 2: Answer with full context ===
-	log.Println("=== Q&A Pass 2: Generating answer ===")
-
-	fullContext := diffCtx.GetContextForQAWithAdditional(
-		cfg.CommentPath, cfg.CommentEndLine,
-		additionalFiles, signaturesStr,
-	)
-
-	answer, err := role.RunQA(
-		context.Background(), llm, cfg,
-		fullContext, overview,
-	)
+	finishReport(cfg, err)
 	if err != nil {
-		log.Fatalf("Q&A failed: %v", err)
+		fatal(cmd.name+" failed", err)
 	}
-
-	writeAnswer(answer)
 }
 
-// runQAFallback handles Q&A when diff context is not available
-func runQAFallback(llm provider.Provider, cfg *config.Config, overview string) {
-	taskMetadata := &ctx.TaskMetadata{}
-	codebaseCtx := ctx.GetCodebaseContext(taskMetadata)
-
-	answer, err := role.RunQA(
-		context.Background(), llm, cfg,
-		codebaseCtx.GetContextForAnalysis(), overview,
-	)
-	if err != nil {
-		log.Fatalf("Q&A failed: %v", err)
+func usage() {
+	var b strings.Builder
+	b.WriteString("Usage: agent <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		b.WriteString(fmt.Sprintf("  %-10s %s\n", cmd.name, cmd.summary))
 	}
-	writeAnswer(answer)
+	b.WriteString("\nRun 'agent <command> -h' for command flags.\n")
+	b.WriteString("Flags fall back to environment variables (TASK_ID, PR_NUMBER, AGENT_PROVIDER, ...).\n")
+	fmt.Fprint(os.Stderr, b.String())
 }
 
-func writeAnswer(answer string) {
-	if err := os.WriteFile("answer.md", []byte(answer), 0644); err != nil {
-		log.Fatalf("Failed to write answer: %v", err)
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
 	}
-	log.Println("Answer written to answer.md")
+	return nil
 }
 
-func runCoderMode(llm provider.Provider, cfg *config.Config) {
-	log.Printf("--- CODER AGENT STARTED (Task: %s) ---", cfg.TaskID)
-
-	if cfg.Feedback != "" {
-		log.Printf("Feedback received: %s", cfg.Feedback)
-	}
-
-	// Load context
-	overview := ctx.GetOverviewDoc()
-	instruction, err := ctx.GetInstructionDoc(cfg.TaskID)
-	if err != nil {
-		log.Fatalf("Failed to load task instructions: %v", err)
-	}
-
-	// Parse task metadata (TARGET FILES, DEPENDS_ON)
-	taskMetadata := ctx.ParseTaskMetadata(instruction)
-	log.Printf("Target files from task: %v", taskMetadata.TargetFiles)
-
-	var dependentContext string
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
 
-	if len(taskMetadata.DependsOn) > 0 {
-		log.Printf("Task depends on: %v", taskMetadata.DependsOn)
-		dependentContext = ctx.GetDependentContext(taskMetadata.DependsOn)
+// runLegacy keeps the --mode flag interface working for existing workflows
+func runLegacy(cfg *config.Config, args []string) error {
+
+	fs := flag.NewFlagSet("agent", flag.ContinueOnError)
+	fs.StringVar(&cfg.Mode, "mode", cfg.Mode, "Agent mode: coder, reviewer or summary")
+	fs.StringVar(&cfg.TaskID, "task", cfg.TaskID, "Task ID")
+	addProviderFlags(fs, cfg)
+	addReviewerFlags(fs, cfg)
+	addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
 
-	// Build initial context (targets full, others signatures)
-	codebaseCtx := ctx.GetCodebaseContext(taskMetadata)
-	log.Printf("Loaded %d target files, %d signature files",
-		len(codebaseCtx.TargetFiles), len(codebaseCtx.SignatureFiles))
-
-	// === Pass 1: Analysis ===
-	log.Println("=== Pass 1: Analyzing task and identifying files ===")
-
-	analysisContext := codebaseCtx.GetContextForAnalysis()
-	if dependentContext != "" {
-		analysisContext = dependentContext + "\n\n" + analysisContext
+	if cfg.TaskID == "" {
+		cfg.TaskID = "01"
 	}
 
-	analysis, err := role.RunAnalysis(
-		context.Background(), llm,
-		&role.AnalysisRequest{
-			Mode:        role.AnalysisModeCoder,
-			Instruction: instruction,
-			Context:     analysisContext,
-			Overview:    overview,
-		},
-	)
+	mode, err := legacyMode(cfg.Mode, cfg.PRQuestion)
 	if err != nil {
-		log.Printf("Warning: Analysis failed, proceeding with target files only: %v", err)
-	} else {
-		additionalPaths := analysis.GetAdditionalFilePaths()
-		if len(additionalPaths) > 0 {
-			log.Printf("Pass 1 identified additional files: %v", additionalPaths)
-			codebaseCtx.ReloadFiles(additionalPaths)
+		return err
+	}
+	if mode == "ask" {
+		slog.Info("PR_QUESTION is set, running ask", "requested_mode", cfg.Mode)
+		runReport.Mode = "ask"
+		llm, err := setup(cfg, false)
+		if err != nil {
+			return err
 		}
+		return runQAMode(llm, cfg)
 	}
 
-	// === Pass 2: Implementation ===
-	log.Println("=== Pass 2: Generating implementation ===")
-	generated, err := role.RunCoder(
-		context.Background(), llm, cfg,
-		instruction, codebaseCtx.GetContextForImplementation(), overview,
-	)
-	if err != nil {
-		log.Fatalf("Code generation failed: %v", err)
-	}
+	runReport.Mode = cfg.Mode
 
-	// Parse and write files
-	files := parser.ParseFiles(generated)
-	if len(files) == 0 {
-		log.Println("Warning: Coder generated no file output.")
-		return
+	llm, err := setup(cfg, cfg.Mode == "reviewer")
+	if err != nil {
+		return err
 	}
 
-	count, err := parser.WriteFiles(files)
-	if err != nil {
-		log.Fatalf("Failed to write files: %v", err)
+	switch cfg.Mode {
+	case "reviewer":
+		return runReviewerMode(llm, cfg)
+	case "summary":
+		return runSummaryMode(llm, cfg)
+	default:
+		_, err := runCoderMode(llm, cfg)
+		return err
 	}
-	log.Printf("Coder wrote %d files to disk.", count)
 }
 
-func runReviewerMode(llm provider.Provider, cfg *config.Config) {
-	log.Println("--- REVIEWER AGENT STARTED ---")
-
-	if cfg.PRNumber == "" {
-		log.Fatal("PR_NUMBER is required for reviewer mode")
+// legacyMode is the mode runLegacy runs. /ask comments arrive with the default
+// mode=coder and PR_QUESTION set; any other mode with a question is a conflict.
+func legacyMode(mode, question string) (string, error) {
+	if question == "" {
+		return mode, nil
 	}
-
-	// Load context
-	instruction, err := ctx.GetInstructionDoc(cfg.TaskID)
-	if err != nil {
-		log.Fatalf("Failed to load task instructions: %v", err)
+	switch mode {
+	case "", "coder", "ask":
+		return "ask", nil
 	}
+	return "", fmt.Errorf("PR_QUESTION is set but mode is %s, unset one of them", mode)
+}
 
-	taskMetadata := ctx.ParseTaskMetadata(instruction)
-	codebaseCtx := ctx.GetCodebaseContext(taskMetadata)
+//--- Shared command helpers ---//
 
-	// Generate review
-	review, err := role.RunReviewer(
-		context.Background(), llm,
-		instruction, codebaseCtx.GetContextForImplementation(),
-	)
-	if err != nil {
-		log.Fatalf("Review generation failed: %v", err)
+func newFlagSet(name, synopsis, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: agent %s %s\n\n%s\n\nFlags:\n", name, synopsis, description)
+		fs.PrintDefaults()
 	}
+	addLogFlags(fs)
+	return fs
+}
 
-	fmt.Println("=== REVIEW CONTENT ===")
-	fmt.Println(review)
-	fmt.Println("=== END REVIEW ===")
+// addProviderFlags binds provider flags, defaulting to the environment
+func addProviderFlags(fs *flag.FlagSet, cfg *config.Config) {
+	fs.StringVar(&cfg.Provider, "provider", cfg.Provider, "LLM provider: claude, gemini, openai (env AGENT_PROVIDER)")
+	fs.StringVar(&cfg.Model, "model", cfg.Model, "Model override (env AGENT_MODEL)")
+	fs.IntVar(&cfg.MaxRetries, "max-retries", cfg.MaxRetries, "API retry attempts (env MAX_RETRIES)")
+	fs.StringVar(&cfg.TasksDir, "tasks-dir", cfg.TasksDir, "Task documents directory (env TASKS_DIR)")
+}
 
-	log.Println("Review generated. Submitting to GitHub...")
+func addReviewerFlags(fs *flag.FlagSet, cfg *config.Config) {
+	fs.StringVar(&cfg.ReviewerProvider, "reviewer-provider", cfg.ReviewerProvider, "LLM provider for reviews, defaults to --provider (env REVIEWER_PROVIDER)")
+	fs.StringVar(&cfg.ReviewerModel, "reviewer-model", cfg.ReviewerModel, "Model override for reviews (env REVIEWER_MODEL)")
+}
 
-	// Determine status
-	eventType := "REQUEST_CHANGES"
-	body := fmt.Sprintf("## AI Review: CHANGES REQUESTED ❌\n\n%s", review)
+func requireFlags(fs *flag.FlagSet, values map[string]string) error {
+	var missing []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if v, ok := values[f.Name]; ok && strings.TrimSpace(v) == "" {
+			missing = append(missing, "--"+f.Name)
+		}
+	})
 
-	if strings.Contains(review, "STATUS: PASS") {
-		eventType = "APPROVE"
-		body = fmt.Sprintf("## AI Review: PASS ✅\n\n%s", review)
+	if len(missing) > 0 {
+		fs.Usage()
+		return fmt.Errorf("missing required flags: %s", strings.Join(missing, ", "))
 	}
+	return nil
+}
 
-	// Submit via gh CLI
-	ghFlag := "--" + strings.ToLower(strings.ReplaceAll(eventType, "_", "-"))
-	cmd := exec.Command("gh", "pr", "review", cfg.PRNumber, ghFlag, "--body", body)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		// Check self-review error
-		log.Printf("Warning: Failed to submit review: %v", err)
-		log.Println("Reviewing your own PR not allowed by Github")
-
-		return
+// setup initializes the task store and the provider for a command
+func setup(cfg *config.Config, reviewer bool) (provider.Provider, error) {
+	if err := initTaskStore(cfg); err != nil {
+		return nil, err
 	}
-	log.Printf("Submitted review: %s", eventType)
+	return newProvider(cfg, reviewer)
 }
 
-func runSummaryMode(llm provider.Provider, cfg *config.Config) {
-	log.Printf("--- SUMMARY AGENT STARTED (Task: %s) ---", cfg.TaskID)
-
-	instruction, err := ctx.GetInstructionDoc(cfg.TaskID)
+func initTaskStore(cfg *config.Config) error {
+	store, err := ctx.NewFSTaskStore(cfg.TasksDir, cfg.TasksOverview, cfg.TasksCompletedSuffix, cfg.TasksIDPattern)
 	if err != nil {
-		log.Fatalf("Failed to load task instructions: %v", err)
+		return fmt.Errorf("failed to initialize task store: %w", err)
 	}
 
-	// Get changed files from environment
-	changedFiles := make(map[string]string)
-	if cfg.ChangedFiles != "" {
-		paths := strings.Split(cfg.ChangedFiles, ",")
-		for _, path := range paths {
-			path = strings.TrimSpace(path)
-			if path == "" {
-				continue
-			}
-			content, err := os.ReadFile(path)
-			if err != nil {
-				log.Printf("Warning: could not read %s: %v", path, err)
-				continue
-			}
-			changedFiles[path] = string(content)
-		}
+	ctx.SetTaskStore(store)
+	prompt.SetLanguageDocLoader(store.LanguageDoc)
+	return nil
+}
+
+// newProvider creates the coder provider, or the reviewer provider if reviewer is set
+func newProvider(cfg *config.Config, reviewer bool) (provider.Provider, error) {
+	creds, err := cfg.CoderCredentials()
+	if reviewer {
+		// Reviewer may use a different model than the coder
+		creds, err = cfg.ReviewerCredentials()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve credentials: %w", err)
 	}
 
-	summary, err := role.GenerateCompletionSummary(
-		context.Background(), llm,
-		&role.SummaryRequest{
-			TaskID:       cfg.TaskID,
-			Instruction:  instruction,
-			FilesChanged: changedFiles,
-			PRNumber:     cfg.PRNumber,
-		},
-	)
+	llm, err := provider.NewProvider(creds, cfg.MaxRetries)
 	if err != nil {
-		log.Fatalf("Summary generation failed: %v", err)
+		return nil, fmt.Errorf("failed to initialize provider: %w", err)
 	}
+	slog.Info("Using provider", "provider", llm.Name())
+	trackedProviders = append(trackedProviders, llm)
 
-	fmt.Print(summary)
+	return llm, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	"github.com/esifea/ai-driven-automation/internal/parser"
+	"github.com/esifea/ai-driven-automation/internal/report"
+	"github.com/esifea/ai-driven-automation/internal/run"
+)
+
+// scriptedLLM returns its responses in order and fails once they run out
+type scriptedLLM struct {
+	responses []string
+	calls     int
+}
+
+func (l *scriptedLLM) Generate(_ context.Context, _ string) (string, error) {
+	if l.calls >= len(l.responses) {
+		return "", fmt.Errorf("unexpected call %d", l.calls+1)
+	}
+	l.calls++
+	return l.responses[l.calls-1], nil
+}
+
+func (l *scriptedLLM) Name() string {
+	return "scripted"
+}
+
+// testRepo makes a temp directory with files the working directory and returns
+// a coder config for task 01, with the run report reset
+func testRepo(t *testing.T, files map[string]string) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	runReport, currentRun = &report.Report{RunID: run.NewID()}, nil
+
+	cfg := &config.Config{
+		TaskID:         "01",
+		EditFormat:     parser.FormatWhole,
+		ScopePolicy:    parser.ScopeWarn,
+		ProtectedPaths: ".git,.github",
+	}
+	if err := initTaskStore(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// fileResponse is a coder response writing one whole file
+func fileResponse(path, content string) string {
+	return fmt.Sprintf("### File: %s\n```\n%s```\n", path, content)
+}
+
+func TestLegacyMode(t *testing.T) {
+	tests := []struct {
+		mode     string
+		question string
+		expected string
+		err      bool
+	}{
+		{"coder", "", "coder", false},
+		{"reviewer", "", "reviewer", false},
+		{"coder", "/ask why?", "ask", false},
+		{"", "/ask why?", "ask", false},
+		{"ask", "/ask why?", "ask", false},
+		{"reviewer", "/ask why?", "", true},
+		{"summary", "/ask why?", "", true},
+	}
+
+	for _, tt := range tests {
+		mode, err := legacyMode(tt.mode, tt.question)
+		if (err != nil) != tt.err || mode != tt.expected {
+			t.Errorf("legacyMode(%q, %q) = %q, %v, want %q", tt.mode, tt.question, mode, err, tt.expected)
+		}
+	}
+}
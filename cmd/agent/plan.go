@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	"github.com/esifea/ai-driven-automation/internal/role"
+)
+
+func runPlan(cfg *config.Config, args []string) error {
+	fs := newFlagSet("plan", "--task ID [flags]",
+		"Run only the analysis pass of coder mode and print, as JSON, the files\nthe model wants to modify or create. Nothing is written to disk.")
+	fs.StringVar(&cfg.TaskID, "task", cfg.TaskID, "Task ID (env TASK_ID)")
+	addProviderFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := requireFlags(fs, map[string]string{"task": cfg.TaskID}); err != nil {
+		return err
+	}
+
+	llm, err := setup(cfg, false)
+	if err != nil {
+		return err
+	}
+
+	in, err := loadCoderInput(cfg.TaskID)
+	if err != nil {
+		return err
+	}
+
+	analysis, err := runAnalysisPass(llm, in)
+	if err != nil {
+		return fmt.Errorf("analysis failed: %w", err)
+	}
+
+	plan := struct {
+		TaskID      string               `json:"task_id"`
+		TargetFiles []string             `json:"target_files"`
+		DependsOn   []string             `json:"depends_on,omitempty"`
+		Analysis    *role.AnalysisResult `json:"analysis"`
+	}{
+		TaskID:      cfg.TaskID,
+		TargetFiles: in.metadata.TargetFiles,
+		DependsOn:   in.metadata.DependsOn,
+		Analysis:    analysis,
+	}
+
+	out, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+
+	fmt.Println(string(out))
+	return nil
+}
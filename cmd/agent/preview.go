@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	ctx "github.com/esifea/ai-driven-automation/internal/context"
+	"github.com/esifea/ai-driven-automation/internal/role"
+)
+
+type promptSection struct {
+	name string
+	size int
+}
+
+type builtPrompt struct {
+	name     string
+	text     string
+	sections []promptSection
+}
+
+func runPrompt(cfg *config.Config, args []string) error {
+	fs := newFlagSet("prompt", "--mode MODE [--task ID] [flags]",
+		"Build the exact prompts a mode would send, without calling the provider.\nModes: coder (analysis + implementation), reviewer, ask, summary.\nPrompts go to stdout (or --out), section sizes and token estimates to stderr.")
+	mode := fs.String("mode", "coder", "Mode to preview: coder, reviewer, ask, summary")
+	files := fs.String("files", "", "Comma-separated files to include in full, as if selected by the analysis pass\n(summary mode: the changed files)")
+	outDir := fs.String("out", "", "Write each prompt to a file in this directory instead of stdout")
+	fs.StringVar(&cfg.TaskID, "task", cfg.TaskID, "Task ID (env TASK_ID)")
+	fs.StringVar(&cfg.Feedback, "feedback", cfg.Feedback, "Reviewer feedback (env FEEDBACK)")
+	fs.StringVar(&cfg.PRQuestion, "question", cfg.PRQuestion, "Question for ask mode (env PR_QUESTION)")
+	fs.StringVar(&cfg.BaseBranch, "base", cfg.BaseBranch, "Base branch for ask mode (env BASE_BRANCH)")
+	fs.StringVar(&cfg.CommentPath, "path", cfg.CommentPath, "File the question is about (env COMMENT_PATH)")
+	fs.StringVar(&cfg.CommentEndLine, "end-line", cfg.CommentEndLine, "Line the question is about (env COMMENT_END_LINE)")
+	fs.StringVar(&cfg.TasksDir, "tasks-dir", cfg.TasksDir, "Task documents directory (env TASKS_DIR)")
+	addCoderFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *mode == "ask" {
+		if err := requireFlags(fs, map[string]string{"question": cfg.PRQuestion}); err != nil {
+			return err
+		}
+	} else if err := requireFlags(fs, map[string]string{"task": cfg.TaskID}); err != nil {
+		return err
+	}
+
+	if err := initTaskStore(cfg); err != nil {
+		return err
+	}
+
+	var prompts []builtPrompt
+	var err error
+
+	switch *mode {
+	case "coder":
+		prompts, err = previewCoder(cfg, splitList(*files))
+	case "reviewer":
+		prompts, err = previewReviewer(cfg)
+	case "ask":
+		prompts, err = previewAsk(cfg, splitList(*files))
+	case "summary":
+		prompts, err = previewSummary(cfg, *files)
+	default:
+		return fmt.Errorf("unknown mode %q", *mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, p := range prompts {
+		if *outDir != "" {
+			if err := os.MkdirAll(*outDir, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", *outDir, err)
+			}
+			path := filepath.Join(*outDir, strings.ReplaceAll(p.name, "/", "_")+".txt")
+			if err := os.WriteFile(path, []byte(p.text), 0644); err != nil {
+				return fmt.Errorf("failed to write prompt: %w", err)
+			}
+			slog.Info("Wrote prompt", "path", path)
+		} else {
+			fmt.Printf("===== PROMPT: %s =====\n%s\n===== END PROMPT: %s =====\n\n", p.name, p.text, p.name)
+		}
+
+		fmt.Fprint(os.Stderr, formatPromptSizes(p))
+	}
+
+	return nil
+}
+
+func previewCoder(cfg *config.Config, files []string) ([]builtPrompt, error) {
+	in, err := loadCoderInput(cfg.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := builtPrompt{
+		name: "coder/analysis",
+		text: role.BuildAnalysisPrompt(&role.AnalysisRequest{
+			Mode:        role.AnalysisModeCoder,
+			Instruction: in.instruction,
+			Context:     in.analysisContext,
+			Overview:    in.overview,
+		}),
+		sections: []promptSection{
+			{"overview", len(in.overview)},
+			{"task instructions", len(in.instruction)},
+			{"dependent tasks", len(in.dependentContext)},
+			{"target files", mapSize(in.codebase.TargetFiles)},
+			{"signatures", mapSize(in.codebase.SignatureFiles)},
+		},
+	}
+
+	// Simulate the files the analysis pass would select
+	if len(files) > 0 {
+		in.codebase.ReloadFiles(files)
+	}
+
+	implementation := builtPrompt{
+		name: "coder/implementation",
+		text: role.BuildCoderPrompt(cfg, in.instruction, in.codebase.GetContextForImplementation(), in.overview),
+		sections: []promptSection{
+			{"overview", len(in.overview)},
+			{"task instructions", len(in.instruction)},
+			{"target files", mapSize(in.codebase.TargetFiles)},
+			{"additional files", mapSize(in.codebase.AdditionalFiles)},
+			{"signatures", mapSize(in.codebase.SignatureFiles)},
+			{"feedback", len(cfg.Feedback)},
+		},
+	}
+
+	return []builtPrompt{analysis, implementation}, nil
+}
+
+func previewReviewer(cfg *config.Config) ([]builtPrompt, error) {
+	instruction, err := ctx.GetInstructionDoc(cfg.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task instructions: %w", err)
+	}
+
+	codebaseCtx := ctx.GetCodebaseContext(ctx.ParseTaskMetadata(instruction))
+
+	return []builtPrompt{{
+		name: "reviewer",
+		text: role.BuildReviewerPrompt(instruction, codebaseCtx.GetContextForImplementation()),
+		sections: []promptSection{
+			{"task instructions", len(instruction)},
+			{"target files", mapSize(codebaseCtx.TargetFiles)},
+			{"signatures", mapSize(codebaseCtx.SignatureFiles)},
+		},
+	}}, nil
+}
+
+func previewAsk(cfg *config.Config, files []string) ([]builtPrompt, error) {
+	overview := ctx.GetOverviewDoc()
+	codebaseCtx := ctx.GetCodebaseContext(&ctx.TaskMetadata{})
+
+	diffCtx, err := ctx.GetDiffContext(cfg.BaseBranch)
+	if err != nil {
+		slog.Warn("Could not get diff context", "error", err)
+		return []builtPrompt{{
+			name: "ask/fallback",
+			text: role.BuildQAPrompt(cfg, codebaseCtx.GetContextForAnalysis(), overview),
+			sections: []promptSection{
+				{"overview", len(overview)},
+				{"signatures", mapSize(codebaseCtx.SignatureFiles)},
+			},
+		}}, nil
+	}
+
+	additionalFiles := diffCtx.LoadAdditionalFiles(files)
+
+	analysis := builtPrompt{
+		name: "ask/analysis",
+		text: role.BuildAnalysisPrompt(qaAnalysisRequest(cfg, diffCtx, codebaseCtx, overview)),
+		sections: []promptSection{
+			{"overview", len(overview)},
+			{"diff", len(diffCtx.Diff)},
+			{"signatures", mapSize(codebaseCtx.SignatureFiles)},
+		},
+	}
+
+	answer := builtPrompt{
+		name: "ask/answer",
+		text: role.BuildQAPrompt(cfg, qaAnswerContext(cfg, diffCtx, codebaseCtx, additionalFiles), overview),
+		sections: []promptSection{
+			{"overview", len(overview)},
+			{"diff", len(diffCtx.Diff)},
+			{"changed files", mapSize(diffCtx.FilesBefore) + mapSize(diffCtx.FilesAfter)},
+			{"additional files", mapSize(additionalFiles)},
+			{"signatures", mapSize(codebaseCtx.SignatureFiles)},
+		},
+	}
+
+	return []builtPrompt{analysis, answer}, nil
+}
+
+func previewSummary(cfg *config.Config, files string) ([]builtPrompt, error) {
+	instruction, err := ctx.GetInstructionDoc(cfg.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task instructions: %w", err)
+	}
+
+	if files == "" {
+		files = cfg.ChangedFiles
+	}
+	changedFiles := loadChangedFiles(files)
+
+	return []builtPrompt{{
+		name: "summary",
+		text: role.BuildSummaryPrompt(&role.SummaryRequest{
+			TaskID:       cfg.TaskID,
+			Instruction:  instruction,
+			FilesChanged: changedFiles,
+			PRNumber:     cfg.PRNumber,
+		}),
+		sections: []promptSection{
+			{"task instructions", len(instruction)},
+			{"changed files", mapSize(changedFiles)},
+		},
+	}}, nil
+}
+
+func formatPromptSizes(p builtPrompt) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s: %d chars, ~%d tokens\n", p.name, len(p.text), role.EstimateTokens(p.text)))
+	for _, s := range p.sections {
+		b.WriteString(fmt.Sprintf("  %-18s %9d chars  ~%8d tokens\n", s.name, s.size, role.EstimateTokensForSize(s.size)))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func mapSize(m map[string]string) int {
+	size := 0
+	for _, content := range m {
+		size += len(content)
+	}
+	return size
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
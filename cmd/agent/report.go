@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	"github.com/esifea/ai-driven-automation/internal/parser"
+	"github.com/esifea/ai-driven-automation/internal/provider"
+	"github.com/esifea/ai-driven-automation/internal/report"
+	"github.com/esifea/ai-driven-automation/internal/run"
+)
+
+// runReport collects the results of the current command
+var runReport = &report.Report{RunID: run.NewID()}
+
+// currentRun holds the artifacts and checkpoints of this invocation
+var currentRun *run.Run
+
+// openRun creates the run directory on first use
+func openRun(root string) (*run.Run, error) {
+	if currentRun == nil {
+		r, err := run.New(root, runReport.RunID)
+		if err != nil {
+			return nil, err
+		}
+		currentRun = r
+	}
+	return currentRun, nil
+}
+
+// Providers whose token usage is added to the report
+var trackedProviders []provider.Provider
+
+// recordFilesWritten adds paths to the report, keeping it sorted and unique
+func recordFilesWritten(paths []string) {
+	seen := make(map[string]bool)
+	for _, p := range runReport.FilesWritten {
+		seen[p] = true
+	}
+
+	for _, p := range paths {
+		if !seen[p] {
+			runReport.FilesWritten = append(runReport.FilesWritten, p)
+			seen[p] = true
+		}
+	}
+	sort.Strings(runReport.FilesWritten)
+}
+
+// recordChanges adds the changed files to the report. Across runs (loop), a file
+// created earlier stays created when modified and drops out when deleted again.
+func recordChanges(changes []parser.Change) {
+	for _, c := range changes {
+		i := slices.IndexFunc(runReport.Changes, func(prev parser.Change) bool { return prev.Path == c.Path })
+		switch {
+		case i < 0:
+			runReport.Changes = append(runReport.Changes, c)
+		case runReport.Changes[i].Action != parser.ActionCreated:
+			runReport.Changes[i] = c
+		case c.Action == parser.ActionDeleted:
+			runReport.Changes = slices.Delete(runReport.Changes, i, i+1)
+			runReport.FilesWritten = slices.DeleteFunc(runReport.FilesWritten, func(p string) bool { return p == c.Path })
+			continue
+		}
+		recordFilesWritten([]string{c.Path})
+	}
+	sort.Slice(runReport.Changes, func(i, j int) bool { return runReport.Changes[i].Path < runReport.Changes[j].Path })
+}
+
+func changedPaths(changes []parser.Change) []string {
+	paths := make([]string, 0, len(changes))
+	for _, c := range changes {
+		paths = append(paths, c.Path)
+	}
+	return paths
+}
+
+// finishReport writes $GITHUB_OUTPUT and $GITHUB_STEP_SUMMARY when running in Actions
+func finishReport(cfg *config.Config, err error) {
+	runReport.Status = report.StatusSuccess
+	if err != nil {
+		runReport.Status = report.StatusFailure
+		if errors.Is(err, context.Canceled) || rootCtx.Err() != nil {
+			runReport.Status = report.StatusCanceled
+		}
+		runReport.Error = err.Error()
+	}
+
+	if runReport.Mode != "ask" {
+		runReport.TaskID = cfg.TaskID
+	}
+
+	for _, p := range trackedProviders {
+		if reporter, ok := p.(provider.UsageReporter); ok {
+			runReport.Usage = runReport.Usage.Add(reporter.Usage())
+		}
+	}
+
+	if currentRun != nil {
+		if err := currentRun.WriteJSON("report.json", runReport); err != nil {
+			slog.Warn("Could not write run report", "error", err)
+		}
+	}
+
+	if path := os.Getenv("GITHUB_OUTPUT"); path != "" {
+		if err := runReport.WriteGitHubOutputs(path); err != nil {
+			slog.Warn("Could not write GitHub outputs", "error", err)
+		}
+	}
+
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := runReport.WriteStepSummary(path); err != nil {
+			slog.Warn("Could not write step summary", "error", err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	"github.com/esifea/ai-driven-automation/internal/provider"
+	"github.com/esifea/ai-driven-automation/internal/run"
+)
+
+func runResume(cfg *config.Config, args []string) error {
+	fs := newFlagSet("resume", "<run-id> [flags]",
+		"Continue a coder run from its last checkpoint in .agent/runs/<run-id>/\n(<run-id>/iter-NN for a loop iteration). A saved analysis is reused,\nand a saved coder response is re-parsed without calling the model.")
+	runRoot := fs.String("run-dir", run.DefaultRoot, "Directory holding run artifacts")
+	addProviderFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one run ID")
+	}
+
+	r, err := run.Open(*runRoot, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	state, err := r.LoadState()
+	if err != nil {
+		return err
+	}
+	if state.Stage == run.StageDone {
+		return fmt.Errorf("run %s already completed", r.ID)
+	}
+
+	// Continue under the original run so logs and artifacts line up
+	runReport.RunID = r.ID
+	currentRun = r
+
+	cfg.TaskID = state.TaskID
+	cfg.Feedback = state.Feedback
+	cfg.DryRun = state.DryRun
+	cfg.PatchFile = state.PatchFile
+	if state.EditFormat != "" {
+		cfg.EditFormat = state.EditFormat
+	}
+	if g := state.Guards; g != nil {
+		cfg.ProtectedPaths = g.ProtectedPaths
+		cfg.ScopePolicy = g.ScopePolicy
+		cfg.SyntaxChecks = g.SyntaxChecks
+		cfg.MaxShrink = g.MaxShrink
+	}
+
+	slog.Info("Resuming run", "stage", state.Stage, "dir", r.Dir)
+
+	if err := initTaskStore(cfg); err != nil {
+		return err
+	}
+
+	// The model is only needed while the coder response is missing,
+	// or to re-prompt for edit blocks that fail to apply and syntax errors
+	var llm provider.Provider
+	switch {
+	case !state.Stage.Reached(run.StageResponse):
+		llm, err = newProvider(cfg, false)
+		if err != nil {
+			return err
+		}
+	case !state.Stage.Reached(run.StageFiles):
+		llm, err = newProvider(cfg, false)
+		if err != nil {
+			slog.Warn("No provider, failed edits, elided files and syntax errors will not be repaired", "error", err)
+		}
+	}
+
+	_, err = runCoderStages(llm, cfg, r, state)
+	return err
+}
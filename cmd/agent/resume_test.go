@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/esifea/ai-driven-automation/internal/parser"
+	"github.com/esifea/ai-driven-automation/internal/run"
+)
+
+// savedRun checkpoints a coder run at stage with the given run files
+func savedRun(t *testing.T, root string, state *run.State, files map[string]any) {
+	t.Helper()
+	r, err := run.New(root, "saved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if s, ok := content.(string); ok {
+			err = r.WriteFile(name, []byte(s))
+		} else {
+			err = r.WriteJSON(name, content)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.SaveState(state); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResume(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   run.State
+		files   map[string]any
+		written string
+		err     string
+	}{
+		{
+			name:    "saved response is re-parsed without the model",
+			state:   run.State{TaskID: "01", Stage: run.StageResponse},
+			files:   map[string]any{run.ResponseFile: fileResponse("in/a.txt", "from response\n")},
+			written: "from response\n",
+		},
+		{
+			name:    "saved files are written",
+			state:   run.State{TaskID: "01", Stage: run.StageFiles},
+			files:   map[string]any{run.FilesFile: map[string]string{"in/a.txt": "from files\n"}},
+			written: "from files\n",
+		},
+		{
+			name:  "completed run",
+			state: run.State{TaskID: "01", Stage: run.StageDone},
+			err:   "already completed",
+		},
+		{
+			name: "saved scope policy applies",
+			state: run.State{
+				TaskID: "01",
+				Stage:  run.StageFiles,
+				Guards: &run.Guards{ScopePolicy: parser.ScopeError},
+			},
+			files: map[string]any{run.FilesFile: map[string]string{"out/b.txt": "x\n"}},
+			err:   "outside the task scope",
+		},
+		{
+			name: "saved protected paths apply",
+			state: run.State{
+				TaskID: "01",
+				Stage:  run.StageFiles,
+				Guards: &run.Guards{ProtectedPaths: "in", ScopePolicy: parser.ScopeWarn},
+			},
+			files: map[string]any{run.FilesFile: map[string]string{"in/a.txt": "x\n"}},
+			err:   "rejected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testRepo(t, map[string]string{
+				"docs/tasks/01_task.md": "# Task\n\nTARGET FILES:\n- in/a.txt\n",
+			})
+			root := filepath.Join(t.TempDir(), "runs")
+			savedRun(t, root, &tt.state, tt.files)
+
+			err := runResume(cfg, []string{"--run-dir", root, "saved"})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if data, _ := os.ReadFile("in/a.txt"); string(data) != tt.written {
+				t.Errorf("in/a.txt = %q, want %q", data, tt.written)
+			}
+			r, err := run.Open(root, "saved")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state, err := r.LoadState(); err != nil || state.Stage != run.StageDone {
+				t.Errorf("state after resume = %+v, %v", state, err)
+			}
+		})
+	}
+}
+
+func TestRunCoderStages(t *testing.T) {
+	tests := []struct {
+		name  string
+		stage run.Stage
+		files map[string]any
+		calls int
+	}{
+		{"from the start", run.StageStarted, nil, 2},
+		{"saved analysis is reused", run.StageAnalysis, map[string]any{run.AnalysisFile: map[string]any{}}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testRepo(t, map[string]string{
+				"docs/tasks/01_task.md": "# Task\n\nTARGET FILES:\n- a.txt\n",
+			})
+			root := filepath.Join(t.TempDir(), "runs")
+			state := &run.State{TaskID: "01", Stage: tt.stage}
+			savedRun(t, root, state, tt.files)
+			r, err := run.Open(root, "saved")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Only the passes not saved yet call the model
+			llm := &scriptedLLM{responses: []string{fileResponse("a.txt", "done\n")}}
+			if tt.calls == 2 {
+				llm.responses = append([]string{"{}"}, llm.responses...)
+			}
+			if _, err := runCoderStages(llm, cfg, r, state); err != nil {
+				t.Fatal(err)
+			}
+
+			if llm.calls != tt.calls {
+				t.Errorf("model called %d times, want %d", llm.calls, tt.calls)
+			}
+			if state.Stage != run.StageDone {
+				t.Errorf("stage = %s, want done", state.Stage)
+			}
+			for _, name := range []string{run.ResponseFile, run.FilesFile} {
+				if !r.Exists(name) {
+					t.Errorf("%s should be checkpointed", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	ctx "github.com/esifea/ai-driven-automation/internal/context"
+	"github.com/esifea/ai-driven-automation/internal/provider"
+	"github.com/esifea/ai-driven-automation/internal/role"
+)
+
+func runReview(cfg *config.Config, args []string) error {
+	fs := newFlagSet("review", "--task ID --pr NUMBER [flags]",
+		"Review the working tree against a task's instructions and submit\nthe verdict as a GitHub PR review (requires the gh CLI).")
+	fs.StringVar(&cfg.TaskID, "task", cfg.TaskID, "Task ID (env TASK_ID)")
+	fs.StringVar(&cfg.PRNumber, "pr", cfg.PRNumber, "Pull request number (env PR_NUMBER)")
+	addProviderFlags(fs, cfg)
+	addReviewerFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := requireFlags(fs, map[string]string{"task": cfg.TaskID, "pr": cfg.PRNumber}); err != nil {
+		return err
+	}
+
+	llm, err := setup(cfg, true)
+	if err != nil {
+		return err
+	}
+
+	return runReviewerMode(llm, cfg)
+}
+
+func runReviewerMode(llm provider.Provider, cfg *config.Config) error {
+	slog.Info("Reviewer agent started")
+
+	if cfg.PRNumber == "" {
+		return fmt.Errorf("PR_NUMBER is required for reviewer mode")
+	}
+
+	review, err := generateReview(llm, cfg.TaskID)
+	if err != nil {
+		return err
+	}
+
+	runReport.Verdict, runReport.Review = reviewVerdict(review), review
+
+	fmt.Println(review)
+
+	slog.Info("Review generated, submitting to GitHub")
+
+	// Determine status
+	eventType := "REQUEST_CHANGES"
+	body := fmt.Sprintf("## AI Review: CHANGES REQUESTED ❌\n\n%s", review)
+
+	if reviewVerdict(review) == verdictPass {
+		eventType = "APPROVE"
+		body = fmt.Sprintf("## AI Review: PASS ✅\n\n%s", review)
+	}
+
+	// Submit via gh CLI
+	ghFlag := "--" + strings.ToLower(strings.ReplaceAll(eventType, "_", "-"))
+	cmd := exec.Command("gh", "pr", "review", cfg.PRNumber, ghFlag, "--body", body)
+	// Only the review goes to stdout, the workflow posts all of it
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		// Check self-review error
+		slog.Warn("Failed to submit review, reviewing your own PR is not allowed by GitHub", "error", err)
+
+		return nil
+	}
+	slog.Info("Submitted review", "event", eventType)
+
+	return nil
+}
+
+const (
+	verdictPass = "PASS"
+	verdictFail = "FAIL"
+)
+
+// generateReview reviews the working tree against the task instructions
+func generateReview(llm provider.Provider, taskID string) (string, error) {
+	// Load context
+	instruction, err := ctx.GetInstructionDoc(taskID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load task instructions: %w", err)
+	}
+
+	taskMetadata := ctx.ParseTaskMetadata(instruction)
+	codebaseCtx := ctx.GetCodebaseContext(taskMetadata)
+
+	// Generate review
+	review, err := role.RunReviewer(
+		rootCtx, llm,
+		instruction, codebaseCtx.GetContextForImplementation(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("review generation failed: %w", err)
+	}
+
+	return review, nil
+}
+
+func reviewVerdict(review string) string {
+	if strings.Contains(review, "STATUS: PASS") {
+		return verdictPass
+	}
+	return verdictFail
+}
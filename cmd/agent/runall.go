@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	ctx "github.com/esifea/ai-driven-automation/internal/context"
+	"github.com/esifea/ai-driven-automation/internal/provider"
+	"github.com/esifea/ai-driven-automation/internal/role"
+)
+
+func runRunAll(cfg *config.Config, args []string) error {
+	fs := newFlagSet("run-all", "[--summarize] [--show-order] [flags]",
+		"Run coder mode on every pending task in DEPENDS_ON order. Tasks with a\ncompleted doc are skipped. Stops at the first failing task.")
+	summarize := fs.Bool("summarize", false, "Write each task's completion summary before its dependents run")
+	showOrder := fs.Bool("show-order", false, "Print the execution order and exit")
+	addCoderFlags(fs, cfg)
+	addProviderFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := initTaskStore(cfg); err != nil {
+		return err
+	}
+
+	store := ctx.GetTaskStore()
+	pending, err := pendingTasks(store)
+	if err != nil {
+		return err
+	}
+
+	if *showOrder {
+		for _, id := range pending {
+			fmt.Println(id)
+		}
+		return nil
+	}
+
+	if len(pending) == 0 {
+		slog.Info("No pending tasks")
+		return nil
+	}
+
+	llm, err := newProvider(cfg, false)
+	if err != nil {
+		return err
+	}
+
+	// Each task writes its files before the next one reads them
+	cfg.DryRun = false
+
+	for i, id := range pending {
+		slog.Info("Starting task", "task", id, "index", i+1, "total", len(pending))
+
+		cfg.TaskID = id
+		cfg.Feedback = ""
+		runReport.TaskID = id
+
+		result, err := runCoderMode(llm, cfg)
+		if err != nil {
+			return fmt.Errorf("task %s failed: %w", id, err)
+		}
+
+		if !*summarize {
+			continue
+		}
+
+		path, err := writeCompletionSummary(llm, store, id, result.files)
+		if err != nil {
+			return fmt.Errorf("task %s summary failed: %w", id, err)
+		}
+		slog.Info("Wrote completion summary", "path", path)
+	}
+
+	slog.Info("Completed tasks", "count", len(pending))
+	return nil
+}
+
+// pendingTasks returns the tasks without a completed doc in DEPENDS_ON order
+func pendingTasks(store ctx.TaskStore) ([]string, error) {
+	graph, err := ctx.BuildTaskGraph(store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build task graph: %w", err)
+	}
+
+	if len(graph.Missing) > 0 {
+		var parts []string
+		for _, id := range sortedGraphKeys(graph.Missing) {
+			parts = append(parts, fmt.Sprintf("%s -> %s", id, strings.Join(graph.Missing[id], ", ")))
+		}
+		return nil, fmt.Errorf("unknown DEPENDS_ON IDs: %s", strings.Join(parts, "; "))
+	}
+
+	order, err := graph.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, id := range order {
+		if graph.Nodes[id].Completed {
+			slog.Info("Skipping completed task", "task", id)
+			continue
+		}
+		pending = append(pending, id)
+	}
+	return pending, nil
+}
+
+// writeCompletionSummary saves the summary where dependent tasks load it from
+func writeCompletionSummary(llm provider.Provider, store ctx.TaskStore, taskID string, files map[string]string) (string, error) {
+	instruction, err := store.Instruction(taskID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load task instructions: %w", err)
+	}
+
+	summary, err := role.GenerateCompletionSummary(
+		rootCtx, llm,
+		&role.SummaryRequest{
+			TaskID:       taskID,
+			Instruction:  instruction,
+			FilesChanged: files,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := store.CompletedPath(taskID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(summary), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return path, nil
+}
+
+func sortedGraphKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
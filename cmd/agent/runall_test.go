@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ctx "github.com/esifea/ai-driven-automation/internal/context"
+)
+
+func TestPendingTasks(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		pending string
+		err     string
+	}{
+		{
+			name: "dependency order, completed skipped",
+			files: map[string]string{
+				"00_overview.md":       "rules",
+				"01_base.md":           "# Base",
+				"01_base_completed.md": "done",
+				"02_api.md":            "DEPENDS_ON: 03, 01",
+				"03_models.md":         "DEPENDS_ON: 01",
+			},
+			pending: "03,02",
+		},
+		{
+			name: "nested dependency relative to the task",
+			files: map[string]string{
+				"auth/01_session.md": "# Session",
+				"auth/02_login.md":   "DEPENDS_ON: 01",
+				"01_setup.md":        "# Setup",
+			},
+			pending: "01,auth/01,auth/02",
+		},
+		{
+			name:  "unknown dependency",
+			files: map[string]string{"01_a.md": "DEPENDS_ON: 09"},
+			err:   "unknown DEPENDS_ON IDs: 01 -> 09",
+		},
+		{
+			name:  "cycle",
+			files: map[string]string{"01_a.md": "DEPENDS_ON: 02", "02_b.md": "DEPENDS_ON: 01"},
+			err:   "cycle",
+		},
+		{
+			name:  "all completed",
+			files: map[string]string{"01_a.md": "# A", "01_a_completed.md": "done"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for path, content := range tt.files {
+				full := filepath.Join(root, path)
+				if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			store, err := ctx.NewFSTaskStore(root, "", "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pending, err := pendingTasks(store)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v, %v", tt.err, pending, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(pending, ","); got != tt.pending {
+				t.Errorf("pendingTasks() = %s, want %s", got, tt.pending)
+			}
+		})
+	}
+}
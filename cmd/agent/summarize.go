@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	ctx "github.com/esifea/ai-driven-automation/internal/context"
+	"github.com/esifea/ai-driven-automation/internal/provider"
+	"github.com/esifea/ai-driven-automation/internal/role"
+)
+
+func runSummarize(cfg *config.Config, args []string) error {
+	fs := newFlagSet("summarize", "--task ID [--files a.go,b.go] [flags]",
+		"Generate a completion summary for a merged task and print it to stdout.")
+	fs.StringVar(&cfg.TaskID, "task", cfg.TaskID, "Task ID (env TASK_ID)")
+	fs.StringVar(&cfg.PRNumber, "pr", cfg.PRNumber, "Pull request number (env PR_NUMBER)")
+	fs.StringVar(&cfg.ChangedFiles, "files", cfg.ChangedFiles, "Comma-separated list of changed files (env CHANGED_FILES)")
+	addProviderFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := requireFlags(fs, map[string]string{"task": cfg.TaskID}); err != nil {
+		return err
+	}
+
+	llm, err := setup(cfg, false)
+	if err != nil {
+		return err
+	}
+
+	return runSummaryMode(llm, cfg)
+}
+
+func runSummaryMode(llm provider.Provider, cfg *config.Config) error {
+	slog.Info("Summary agent started")
+
+	instruction, err := ctx.GetInstructionDoc(cfg.TaskID)
+	if err != nil {
+		return fmt.Errorf("failed to load task instructions: %w", err)
+	}
+
+	summary, err := role.GenerateCompletionSummary(
+		rootCtx, llm,
+		&role.SummaryRequest{
+			TaskID:       cfg.TaskID,
+			Instruction:  instruction,
+			FilesChanged: loadChangedFiles(cfg.ChangedFiles),
+			PRNumber:     cfg.PRNumber,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("summary generation failed: %w", err)
+	}
+
+	fmt.Print(summary)
+
+	return nil
+}
+
+// loadChangedFiles reads a comma-separated list of paths
+func loadChangedFiles(list string) map[string]string {
+	changedFiles := make(map[string]string)
+	if list == "" {
+		return changedFiles
+	}
+
+	paths := strings.Split(list, ",")
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			slog.Warn("Could not read changed file", "path", path, "error", err)
+			continue
+		}
+		changedFiles[path] = string(content)
+	}
+
+	return changedFiles
+}
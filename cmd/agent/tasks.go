@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+	ctx "github.com/esifea/ai-driven-automation/internal/context"
+)
+
+const (
+	taskPending    = "pending"
+	taskInProgress = "in-progress"
+	taskDone       = "done"
+
+	taskBranchPrefix = "refactor/task-"
+)
+
+var taskCommands = []*command{
+	{"list", "Show task status, target files and dependencies", runTasksList},
+	{"lint", "Validate task documents before spending tokens", runTasksLint},
+}
+
+func runTasks(cfg *config.Config, args []string) error {
+	if len(args) == 0 || isHelpFlag(args[0]) {
+		tasksUsage()
+		if len(args) == 0 {
+			return fmt.Errorf("missing tasks subcommand")
+		}
+		return flag.ErrHelp
+	}
+
+	for _, cmd := range taskCommands {
+		if cmd.name == args[0] {
+			return cmd.run(cfg, args[1:])
+		}
+	}
+
+	tasksUsage()
+	return fmt.Errorf("unknown tasks subcommand %q", args[0])
+}
+
+func tasksUsage() {
+	var b strings.Builder
+	b.WriteString("Usage: agent tasks <subcommand> [flags]\n\nSubcommands:\n")
+	for _, cmd := range taskCommands {
+		b.WriteString(fmt.Sprintf("  %-10s %s\n", cmd.name, cmd.summary))
+	}
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+type taskStatus struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title,omitempty"`
+	Status      string   `json:"status"`
+	Path        string   `json:"path"`
+	TargetFiles []string `json:"target_files,omitempty"`
+	DependsOn   []string `json:"depends_on,omitempty"`
+	BlockedBy   []string `json:"blocked_by,omitempty"`
+	Branch      string   `json:"branch,omitempty"`
+}
+
+func runTasksList(cfg *config.Config, args []string) error {
+	fs := newFlagSet("tasks list", "[--format table|json] [--graph mermaid|dot] [flags]",
+		"List every task with its status: done when a completed doc exists,\nin-progress when an open PR branch refactor/task-<ID>-* exists, pending otherwise.")
+	format := fs.String("format", "table", "Output format: table or json")
+	graphFormat := fs.String("graph", "", "Print the dependency graph instead: mermaid or dot")
+	noPRs := fs.Bool("no-prs", false, "Do not query open pull requests with gh")
+	fs.StringVar(&cfg.TasksDir, "tasks-dir", cfg.TasksDir, "Task documents directory (env TASKS_DIR)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := initTaskStore(cfg); err != nil {
+		return err
+	}
+
+	graph, err := ctx.BuildTaskGraph(ctx.GetTaskStore())
+	if err != nil {
+		return fmt.Errorf("failed to build task graph: %w", err)
+	}
+
+	switch *graphFormat {
+	case "":
+	case "mermaid":
+		fmt.Print(graph.Mermaid())
+		return nil
+	case "dot":
+		fmt.Print(graph.DOT())
+		return nil
+	default:
+		return fmt.Errorf("unknown graph format %q (want mermaid or dot)", *graphFormat)
+	}
+
+	var branches []string
+	if !*noPRs {
+		branches = openPRBranches()
+	}
+
+	statuses := taskStatuses(graph, branches)
+
+	switch *format {
+	case "json":
+		out, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode tasks: %w", err)
+		}
+		fmt.Println(string(out))
+	case "table":
+		printTaskTable(statuses)
+	default:
+		return fmt.Errorf("unknown format %q (want table or json)", *format)
+	}
+
+	return nil
+}
+
+func taskStatuses(graph *ctx.TaskGraph, branches []string) []taskStatus {
+	var statuses []taskStatus
+	for _, id := range graph.IDs {
+		node := graph.Nodes[id]
+		s := taskStatus{
+			ID:          id,
+			Title:       node.Metadata.Title,
+			Status:      taskPending,
+			Path:        node.Path,
+			TargetFiles: node.Metadata.TargetFiles,
+			DependsOn:   node.Metadata.DependsOn,
+			BlockedBy:   graph.BlockedBy(id),
+		}
+
+		if node.Completed {
+			s.Status = taskDone
+			s.BlockedBy = nil
+		} else if branch := taskBranch(id, branches); branch != "" {
+			s.Status = taskInProgress
+			s.Branch = branch
+		}
+
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// taskBranch finds the PR branch created by the workflow for a task
+func taskBranch(id string, branches []string) string {
+	prefix := taskBranchPrefix + id + "-"
+	for _, branch := range branches {
+		if strings.HasPrefix(branch, prefix) {
+			return branch
+		}
+	}
+	return ""
+}
+
+// openPRBranches lists head branches of open PRs, nil if gh is unavailable
+func openPRBranches() []string {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return nil
+	}
+
+	out, err := exec.Command("gh", "pr", "list", "--state", "open", "--limit", "200", "--json", "headRefName").Output()
+	if err != nil {
+		slog.Warn("Could not list open PRs", "error", err)
+		return nil
+	}
+
+	var prs []struct {
+		HeadRefName string `json:"headRefName"`
+	}
+	if err := json.Unmarshal(out, &prs); err != nil {
+		slog.Warn("Could not parse gh output", "error", err)
+		return nil
+	}
+
+	var branches []string
+	for _, pr := range prs {
+		branches = append(branches, pr.HeadRefName)
+	}
+	return branches
+}
+
+func printTaskTable(statuses []taskStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tTITLE\tTARGET FILES\tBLOCKED BY")
+	for _, s := range statuses {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			s.ID, s.Status, orDash(s.Title), orDash(strings.Join(s.TargetFiles, ", ")), orDash(strings.Join(s.BlockedBy, ", ")))
+	}
+	w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func runTasksLint(cfg *config.Config, args []string) error {
+	fs := newFlagSet("tasks lint", "[flags]",
+		"Check every task document for missing target files (unless marked '# new'),\nunknown DEPENDS_ON IDs, dependency cycles, an unknown LANGUAGE and missing\nObjective/Requirements sections. Exits non-zero when problems are found.")
+	fs.StringVar(&cfg.TasksDir, "tasks-dir", cfg.TasksDir, "Task documents directory (env TASKS_DIR)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := initTaskStore(cfg); err != nil {
+		return err
+	}
+
+	issues, err := ctx.LintTasks(ctx.GetTaskStore(), ".")
+	if err != nil {
+		return fmt.Errorf("failed to lint tasks: %w", err)
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%d problem(s) found", len(issues))
+	}
+
+	slog.Info("No problems found")
+	return nil
+}
@@ -1,20 +1,36 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
 	// Provider
-	Provider string // claude, gemini, ...
-	APIKey   string
+	Provider string // claude, gemini, openai
+	APIKey   string // Explicit key, otherwise AGENT_API_KEY or the provider's key
+	BaseURL  string // Custom endpoint (e.g. OpenAI-compatible gateway)
+	Model    string // Overrides the provider's default models
+
+	// Reviewer provider (falls back to the coder provider if unset)
+	ReviewerProvider string
+	ReviewerAPIKey   string // Explicit key, otherwise REVIEWER_API_KEY
+	ReviewerBaseURL  string
+	ReviewerModel    string
 
 	// Task
 	Mode     string // coder, reviewer
 	TaskID   string
 	PRNumber string
 
+	// Task store layout
+	TasksDir             string
+	TasksOverview        string
+	TasksCompletedSuffix string
+	TasksIDPattern       string // Regex, first capture group is the task ID
+
 	// Q&A
 	PRQuestion       string
 	CommentPath      string
@@ -23,26 +39,57 @@ type Config struct {
 
 	Feedback string
 
+	// Coder output
+	DryRun     bool   // Print a diff instead of writing files
+	PatchFile  string // Also save the dry-run diff here
+	EditFormat string // whole, search-replace, diff
+
+	ProtectedPaths string  // Comma-separated paths the coder may not write, at any depth
+	SyntaxChecks   string  // Extra checkers as ".ext=command", separated by ";"
+	ScopePolicy    string  // error, warn, note: files outside TARGET FILES and the analysis
+	MaxShrink      float64 // Share of lines a file may lose before it counts as elided, 0 disables
+
 	BaseBranch   string
 	ChangedFiles string
 	MaxRetries   int
+
+	// Diagnostics on stderr
+	LogLevel  string // debug, info, warn, error
+	LogFormat string // text, json
 }
 
 func Load() *Config {
 	return &Config{
-		Provider:         getEnv("AGENT_PROVIDER", "gemini"),
-		APIKey:           getEnv("GEMINI_API_KEY", ""), // TODO: support multi agent
-		Mode:             getEnv("MODE", "coder"),
-		TaskID:           getEnv("TASK_ID", "01"),
-		PRNumber:         getEnv("PR_NUMBER", ""),
-		PRQuestion:       getEnv("PR_QUESTION", ""),
-		CommentPath:      getEnv("COMMENT_PATH", ""),
-		CommentStartLine: getEnv("COMMENT_START_LINE", ""),
-		CommentEndLine:   getEnv("COMMENT_END_LINE", ""),
-		Feedback:         getEnv("FEEDBACK", ""),
-		BaseBranch:       getEnv("BASE_BRANCH", ""),
-		ChangedFiles:     getEnv("CHANGED_FILES", ""),
-		MaxRetries:       getEnvInt("MAX_RETRIES", 5),
+		Provider:             getEnv("AGENT_PROVIDER", "gemini"),
+		BaseURL:              getEnv("AGENT_BASE_URL", ""),
+		Model:                getEnv("AGENT_MODEL", ""),
+		ReviewerProvider:     getEnv("REVIEWER_PROVIDER", ""),
+		ReviewerBaseURL:      getEnv("REVIEWER_BASE_URL", ""),
+		ReviewerModel:        getEnv("REVIEWER_MODEL", ""),
+		Mode:                 getEnv("MODE", "coder"),
+		TaskID:               getEnv("TASK_ID", ""),
+		PRNumber:             getEnv("PR_NUMBER", ""),
+		TasksDir:             getEnv("TASKS_DIR", "docs/tasks"),
+		TasksOverview:        getEnv("TASKS_OVERVIEW", "00_overview.md"),
+		TasksCompletedSuffix: getEnv("TASKS_COMPLETED_SUFFIX", "_completed"),
+		TasksIDPattern:       getEnv("TASKS_ID_PATTERN", `^(\d+)_`),
+		PRQuestion:           getEnv("PR_QUESTION", ""),
+		CommentPath:          getEnv("COMMENT_PATH", ""),
+		CommentStartLine:     getEnv("COMMENT_START_LINE", ""),
+		CommentEndLine:       getEnv("COMMENT_END_LINE", ""),
+		Feedback:             getEnv("FEEDBACK", ""),
+		DryRun:               getEnvBool("DRY_RUN", false),
+		PatchFile:            getEnv("PATCH_FILE", ""),
+		EditFormat:           getEnv("EDIT_FORMAT", "whole"),
+		ProtectedPaths:       getEnv("PROTECTED_PATHS", ".git,.github,go.sum"),
+		SyntaxChecks:         getEnv("SYNTAX_CHECKS", ""),
+		ScopePolicy:          getEnv("SCOPE_POLICY", "warn"),
+		MaxShrink:            getEnvFloat("MAX_SHRINK", 0.5),
+		BaseBranch:           getEnv("BASE_BRANCH", ""),
+		ChangedFiles:         getEnv("CHANGED_FILES", ""),
+		MaxRetries:           getEnvInt("MAX_RETRIES", 5),
+		LogLevel:             getEnv("LOG_LEVEL", "info"),
+		LogFormat:            getEnv("LOG_FORMAT", "text"),
 	}
 }
 
@@ -63,3 +110,41 @@ func getEnvInt(key string, fallback int) int {
 
 	return fallback
 }
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if v, ok := os.LookupEnv(key); ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if v, ok := os.LookupEnv(key); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+
+	return fallback
+}
+
+// getSecret reads KEY, or the file named by KEY_FILE (mounted secrets). A
+// KEY_FILE that cannot be read is an error, not a missing key.
+func getSecret(key string) (string, error) {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v, nil
+	}
+
+	if path := os.Getenv(key + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s_FILE: %w", key, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	return "", nil
+}
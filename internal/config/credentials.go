@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Environment variable holding the API key of each provider
+var providerKeyEnv = map[string]string{
+	"gemini": "GEMINI_API_KEY",
+	"claude": "ANTHROPIC_API_KEY",
+	"openai": "OPENAI_API_KEY",
+}
+
+type Credentials struct {
+	Provider string
+	APIKey   string
+	BaseURL  string
+	Model    string
+}
+
+// NormalizeProvider maps provider aliases to their canonical name
+func NormalizeProvider(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	switch name {
+	case "", "google":
+		return "gemini"
+	case "anthropic":
+		return "claude"
+	default:
+		return name
+	}
+}
+
+// CoderCredentials is used by coder, Q&A and summary modes
+func (c *Config) CoderCredentials() (*Credentials, error) {
+	apiKey, err := explicitKey(c.APIKey, "AGENT_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveCredentials(c.Provider, apiKey, c.BaseURL, c.Model)
+}
+
+// ReviewerCredentials falls back to the coder provider if no reviewer is configured
+func (c *Config) ReviewerCredentials() (*Credentials, error) {
+	reviewerKey, err := explicitKey(c.ReviewerAPIKey, "REVIEWER_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ReviewerProvider == "" && reviewerKey == "" && c.ReviewerBaseURL == "" && c.ReviewerModel == "" {
+		return c.CoderCredentials()
+	}
+
+	provider := c.ReviewerProvider
+	if provider == "" {
+		provider = c.Provider
+	}
+
+	apiKey := reviewerKey
+	if apiKey == "" && NormalizeProvider(provider) == NormalizeProvider(c.Provider) {
+		if apiKey, err = explicitKey(c.APIKey, "AGENT_API_KEY"); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolveCredentials(provider, apiKey, c.ReviewerBaseURL, c.ReviewerModel)
+}
+
+// explicitKey returns key if set, otherwise the secret in env
+func explicitKey(key, env string) (string, error) {
+	if key != "" {
+		return key, nil
+	}
+	return getSecret(env)
+}
+
+func resolveCredentials(provider, apiKey, baseURL, model string) (*Credentials, error) {
+	name := NormalizeProvider(provider)
+
+	keyEnv, ok := providerKeyEnv[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported provider %q", provider)
+	}
+
+	if apiKey == "" {
+		var err error
+		if apiKey, err = getSecret(keyEnv); err != nil {
+			return nil, err
+		}
+	}
+
+	// Custom endpoints (local gateways) may not require a key
+	if apiKey == "" && baseURL == "" {
+		return nil, fmt.Errorf("no API key for provider %s: set %s or %s_FILE", name, keyEnv, keyEnv)
+	}
+
+	return &Credentials{
+		Provider: name,
+		APIKey:   apiKey,
+		BaseURL:  baseURL,
+		Model:    model,
+	}, nil
+}
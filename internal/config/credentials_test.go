@@ -0,0 +1,188 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var secretEnvs = []string{"AGENT_API_KEY", "REVIEWER_API_KEY", "GEMINI_API_KEY", "ANTHROPIC_API_KEY", "OPENAI_API_KEY"}
+
+// clearSecrets unsets every key variable and its _FILE variant for the test
+func clearSecrets(t *testing.T) {
+	for _, env := range secretEnvs {
+		t.Setenv(env, "")
+		t.Setenv(env+"_FILE", "")
+	}
+}
+
+func writeSecret(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCoderCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		env      map[string]string
+		provider string
+		key      string
+		err      string
+	}{
+		{
+			name:     "provider key",
+			cfg:      Config{Provider: "anthropic"},
+			env:      map[string]string{"ANTHROPIC_API_KEY": "ant", "GEMINI_API_KEY": "gem"},
+			provider: "claude",
+			key:      "ant",
+		},
+		{
+			name:     "agent key before provider key",
+			cfg:      Config{Provider: "openai"},
+			env:      map[string]string{"AGENT_API_KEY": "agent", "OPENAI_API_KEY": "oai"},
+			provider: "openai",
+			key:      "agent",
+		},
+		{
+			name:     "explicit key before env",
+			cfg:      Config{Provider: "gemini", APIKey: "explicit"},
+			env:      map[string]string{"AGENT_API_KEY": "agent"},
+			provider: "gemini",
+			key:      "explicit",
+		},
+		{
+			name:     "env before file",
+			cfg:      Config{Provider: "gemini"},
+			env:      map[string]string{"GEMINI_API_KEY": "env", "GEMINI_API_KEY_FILE": "file:from-file\n"},
+			provider: "gemini",
+			key:      "env",
+		},
+		{
+			name:     "file",
+			cfg:      Config{Provider: "gemini"},
+			env:      map[string]string{"GEMINI_API_KEY_FILE": "file:from-file\n"},
+			provider: "gemini",
+			key:      "from-file",
+		},
+		{
+			name: "unreadable file",
+			cfg:  Config{Provider: "gemini"},
+			env:  map[string]string{"GEMINI_API_KEY_FILE": "/nonexistent/secret"},
+			err:  "failed to read GEMINI_API_KEY_FILE",
+		},
+		{
+			name: "no key",
+			cfg:  Config{Provider: "claude"},
+			err:  "no API key for provider claude",
+		},
+		{
+			name:     "base URL without key",
+			cfg:      Config{Provider: "openai", BaseURL: "http://localhost:8080/v1"},
+			provider: "openai",
+		},
+		{
+			name: "unsupported provider",
+			cfg:  Config{Provider: "mistral"},
+			err:  `unsupported provider "mistral"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearSecrets(t)
+			for k, v := range tt.env {
+				if content, ok := strings.CutPrefix(v, "file:"); ok {
+					v = writeSecret(t, content)
+				}
+				t.Setenv(k, v)
+			}
+
+			creds, err := tt.cfg.CoderCredentials()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if creds.Provider != tt.provider || creds.APIKey != tt.key {
+				t.Errorf("got %s/%q, want %s/%q", creds.Provider, creds.APIKey, tt.provider, tt.key)
+			}
+		})
+	}
+}
+
+func TestReviewerCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		env      map[string]string
+		provider string
+		key      string
+		model    string
+	}{
+		{
+			name:     "falls back to coder",
+			cfg:      Config{Provider: "claude", Model: "coder-model", BaseURL: "http://gateway"},
+			env:      map[string]string{"ANTHROPIC_API_KEY": "ant"},
+			provider: "claude",
+			key:      "ant",
+			model:    "coder-model",
+		},
+		{
+			name:     "other provider uses its own key",
+			cfg:      Config{Provider: "claude", ReviewerProvider: "openai"},
+			env:      map[string]string{"AGENT_API_KEY": "agent", "OPENAI_API_KEY": "oai"},
+			provider: "openai",
+			key:      "oai",
+		},
+		{
+			name:     "same provider shares the agent key",
+			cfg:      Config{Provider: "gemini", ReviewerModel: "gemini-2.5-pro"},
+			env:      map[string]string{"AGENT_API_KEY": "agent", "GEMINI_API_KEY": "gem"},
+			provider: "gemini",
+			key:      "agent",
+			model:    "gemini-2.5-pro",
+		},
+		{
+			name:     "reviewer key",
+			cfg:      Config{Provider: "gemini"},
+			env:      map[string]string{"REVIEWER_API_KEY_FILE": "file:rev", "AGENT_API_KEY": "agent"},
+			provider: "gemini",
+			key:      "rev",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearSecrets(t)
+			for k, v := range tt.env {
+				if content, ok := strings.CutPrefix(v, "file:"); ok {
+					v = writeSecret(t, content)
+				}
+				t.Setenv(k, v)
+			}
+
+			creds, err := tt.cfg.ReviewerCredentials()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if creds.Provider != tt.provider || creds.APIKey != tt.key || creds.Model != tt.model {
+				t.Errorf("got %s/%q/%q, want %s/%q/%q", creds.Provider, creds.APIKey, creds.Model, tt.provider, tt.key, tt.model)
+			}
+		})
+	}
+
+	clearSecrets(t)
+	t.Setenv("REVIEWER_API_KEY_FILE", "/nonexistent/secret")
+	if _, err := (&Config{Provider: "gemini"}).ReviewerCredentials(); err == nil {
+		t.Error("an unreadable REVIEWER_API_KEY_FILE should fail")
+	}
+}
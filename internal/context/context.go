@@ -2,8 +2,10 @@ package aicontext
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -15,6 +17,7 @@ var (
 		"vendor":       true,
 		"dist":         true,
 		"bin":          true,
+		".agent":       true,
 	}
 
 	skipFiles = map[string]bool{
@@ -43,50 +46,40 @@ type ContextType struct {
 
 //--- Load contexts from docs ---//
 
-// docs/tasks/00_overview.md
-func GetOverviewDoc() string {
-	path := "docs/tasks/00_overview.md"
-
-	content, err := loadFile(path)
-	if err != nil {
-		return ""
-	}
-
-	return content
+var taskStore TaskStore = &FSTaskStore{
+	Root:            DefaultTasksDir,
+	OverviewFile:    DefaultOverviewFile,
+	CompletedSuffix: DefaultCompletedSuffix,
+	IDPattern:       regexp.MustCompile(DefaultIDPattern),
 }
 
-// docs/tasks/{TASK_ID}_*.md (exclude *_completed.md)
-func GetInstructionDoc(taskID string) (string, error) {
-	pattern := fmt.Sprintf("docs/tasks/%s_*.md", taskID)
+// SetTaskStore replaces the store used by the Get*Doc functions
+func SetTaskStore(store TaskStore) {
+	taskStore = store
+}
 
-	files, err := filepath.Glob(pattern)
-	if err != nil || len(files) == 0 {
-		return "", fmt.Errorf("no instruction file found for Task %s", taskID)
-	}
+func GetTaskStore() TaskStore {
+	return taskStore
+}
 
-	for _, f := range files {
-		if !strings.Contains(f, "_completed") {
-			return loadFile(f)
-		}
-	}
+// {TASKS_DIR}/00_overview.md
+func GetOverviewDoc() string {
+	return taskStore.Overview()
+}
 
-	// XXX: Support multiple instruction files
-	return loadFile(files[0])
+// {TASKS_DIR}/{TASK_ID}_*.md (exclude *_completed.md)
+func GetInstructionDoc(taskID string) (string, error) {
+	return taskStore.Instruction(taskID)
 }
 
-// docs/tasks/{TASK_ID}_*_completed.md
+// {TASKS_DIR}/{TASK_ID}_*_completed.md
 func GetCompletedDoc(taskID string) (string, error) {
-	pattern := fmt.Sprintf("docs/tasks/%s_*_completed.md", taskID)
-
-	files, err := filepath.Glob(pattern)
-	if err != nil || len(files) == 0 {
-		return "", fmt.Errorf("no completed doc found for Task %s", taskID)
-	}
-
-	return loadFile(files[0])
+	return taskStore.Completed(taskID)
 }
 
-func GetDependentContext(dependsOn []string) string {
+// GetDependentContext loads the completed summary, or else the instructions, of
+// each dependency of taskID, resolved as in the task graph
+func GetDependentContext(taskID string, dependsOn []string) string {
 	if len(dependsOn) == 0 {
 		return ""
 	}
@@ -94,20 +87,23 @@ func GetDependentContext(dependsOn []string) string {
 	var b strings.Builder
 	b.WriteString("=== DEPENDENT TASKS CONTEXT ===\n\n")
 
-	for _, taskID := range dependsOn {
-		// Load completed summary
-		if completed, err := GetCompletedDoc(taskID); err == nil {
-			b.WriteString(fmt.Sprintf("--- Task %s (Completed) ---\n", taskID))
-			b.WriteString(completed)
-			b.WriteString("\n\n")
-			continue
-		}
+	for _, dep := range dependsOn {
+		for _, id := range dependencyIDs(taskID, dep) {
+			// Load completed summary
+			if completed, err := GetCompletedDoc(id); err == nil {
+				b.WriteString(fmt.Sprintf("--- Task %s (Completed) ---\n", id))
+				b.WriteString(completed)
+				b.WriteString("\n\n")
+				break
+			}
 
-		// Fallback to original instructions
-		if instruction, err := GetInstructionDoc(taskID); err == nil {
-			b.WriteString(fmt.Sprintf("--- Task %s (Instructions Only) ---\n", taskID))
-			b.WriteString(instruction)
-			b.WriteString("\n\n")
+			// Fallback to original instructions
+			if instruction, err := GetInstructionDoc(id); err == nil {
+				b.WriteString(fmt.Sprintf("--- Task %s (Instructions Only) ---\n", id))
+				b.WriteString(instruction)
+				b.WriteString("\n\n")
+				break
+			}
 		}
 	}
 
@@ -140,7 +136,7 @@ func GetCodebaseContext(taskMetadata *TaskMetadata) *ContextType {
 			return nil
 		}
 
-		if strings.Contains(path, "00_overview.md") {
+		if filepath.Clean(path) == filepath.Clean(taskStore.OverviewPath()) {
 			return nil
 		}
 
@@ -251,6 +247,30 @@ func (c *ContextType) ReloadFiles(paths []string) {
 	}
 }
 
+// ListCodeFiles returns code files under root, skipping excluded directories
+func ListCodeFiles(root string) []string {
+	var files []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if d.IsDir() {
+			if path != root && excludeDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if codeExtensions[filepath.Ext(path)] {
+			files = append(files, path)
+		}
+		return nil
+	})
+
+	return files
+}
+
 func loadFile(path string) (string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -0,0 +1,247 @@
+package aicontext
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type TaskNode struct {
+	ID        string
+	Path      string
+	Completed bool
+	Metadata  *TaskMetadata
+	DependsOn []string // Resolved task IDs
+}
+
+// TaskGraph links tasks through their DEPENDS_ON entries
+type TaskGraph struct {
+	Nodes   map[string]*TaskNode
+	IDs     []string            // Sorted task IDs
+	Missing map[string][]string // Task ID -> DEPENDS_ON entries with no task file
+}
+
+func BuildTaskGraph(store TaskStore) (*TaskGraph, error) {
+	tasks, err := store.Tasks()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &TaskGraph{
+		Nodes:   make(map[string]*TaskNode),
+		Missing: make(map[string][]string),
+	}
+
+	for _, task := range tasks {
+		content, err := loadFile(task.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", task.Path, err)
+		}
+
+		g.Nodes[task.ID] = &TaskNode{
+			ID:        task.ID,
+			Path:      task.Path,
+			Completed: task.CompletedPath != "",
+			Metadata:  ParseTaskMetadata(content),
+		}
+		g.IDs = append(g.IDs, task.ID)
+	}
+	sort.Strings(g.IDs)
+
+	for _, id := range g.IDs {
+		node := g.Nodes[id]
+		for _, dep := range node.Metadata.DependsOn {
+			resolved, ok := g.resolve(id, dep)
+			if !ok {
+				g.Missing[id] = append(g.Missing[id], dep)
+				continue
+			}
+			node.DependsOn = append(node.DependsOn, resolved)
+		}
+	}
+
+	return g, nil
+}
+
+// resolve finds a dependency by exact ID, then relative to the task's directory
+func (g *TaskGraph) resolve(taskID, dep string) (string, bool) {
+	for _, id := range dependencyIDs(taskID, dep) {
+		if _, ok := g.Nodes[id]; ok {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// BlockedBy returns the dependencies of a task that are not completed yet
+func (g *TaskGraph) BlockedBy(id string) []string {
+	node, ok := g.Nodes[id]
+	if !ok {
+		return nil
+	}
+
+	var blocked []string
+	for _, dep := range node.DependsOn {
+		if !g.Nodes[dep].Completed {
+			blocked = append(blocked, dep)
+		}
+	}
+	blocked = append(blocked, g.Missing[id]...)
+
+	return blocked
+}
+
+// Cycles returns each dependency cycle once, as a path of task IDs
+func (g *TaskGraph) Cycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	state := make(map[string]int)
+	var stack []string
+	var cycles [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+
+		for _, dep := range g.Nodes[id].DependsOn {
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				// Cycle from dep back to itself
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == dep {
+						cycle := append([]string{}, stack[i:]...)
+						cycles = append(cycles, append(cycle, dep))
+						break
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+
+	for _, id := range g.IDs {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+
+	return cycles
+}
+
+// TopologicalOrder lists tasks so that dependencies come first, ties by ID
+func (g *TaskGraph) TopologicalOrder() ([]string, error) {
+	if cycles := g.Cycles(); len(cycles) > 0 {
+		var parts []string
+		for _, c := range cycles {
+			parts = append(parts, strings.Join(c, " -> "))
+		}
+		return nil, fmt.Errorf("dependency cycle: %s", strings.Join(parts, "; "))
+	}
+
+	inDegree := make(map[string]int)
+	dependents := make(map[string][]string)
+	for _, id := range g.IDs {
+		for _, dep := range g.Nodes[id].DependsOn {
+			inDegree[id]++
+			dependents[dep] = append(dependents[dep], id)
+		}
+	}
+
+	var ready []string
+	for _, id := range g.IDs {
+		if inDegree[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	var order []string
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+
+		for _, next := range dependents[id] {
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	return order, nil
+}
+
+// Mermaid renders the graph as a Mermaid flowchart, edges point to dependents
+func (g *TaskGraph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("graph TD\n")
+
+	nodeID := func(id string) string {
+		return "t_" + strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(id)
+	}
+
+	for _, id := range g.IDs {
+		label := g.label(id)
+		label = strings.ReplaceAll(label, `"`, "'")
+		if g.Nodes[id].Completed {
+			label += " ✓"
+		}
+		b.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", nodeID(id), label))
+	}
+
+	for _, id := range g.IDs {
+		for _, dep := range g.Nodes[id].DependsOn {
+			b.WriteString(fmt.Sprintf("    %s --> %s\n", nodeID(dep), nodeID(id)))
+		}
+		for _, dep := range g.Missing[id] {
+			b.WriteString(fmt.Sprintf("    missing_%s[\"%s (missing)\"] -.-> %s\n", nodeID(dep), dep, nodeID(id)))
+		}
+	}
+
+	return b.String()
+}
+
+// DOT renders the graph in Graphviz format, edges point to dependents
+func (g *TaskGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph tasks {\n")
+	b.WriteString("    rankdir=LR;\n")
+
+	for _, id := range g.IDs {
+		attrs := fmt.Sprintf("label=%q", g.label(id))
+		if g.Nodes[id].Completed {
+			attrs += ", style=filled, fillcolor=palegreen"
+		}
+		b.WriteString(fmt.Sprintf("    %q [%s];\n", id, attrs))
+	}
+
+	for _, id := range g.IDs {
+		for _, dep := range g.Nodes[id].DependsOn {
+			b.WriteString(fmt.Sprintf("    %q -> %q;\n", dep, id))
+		}
+		for _, dep := range g.Missing[id] {
+			b.WriteString(fmt.Sprintf("    %q [style=dashed, color=red];\n", dep))
+			b.WriteString(fmt.Sprintf("    %q -> %q [style=dashed];\n", dep, id))
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (g *TaskGraph) label(id string) string {
+	if title := g.Nodes[id].Metadata.Title; title != "" {
+		return id + ": " + title
+	}
+	return id
+}
@@ -0,0 +1,125 @@
+package aicontext
+
+import (
+	"strings"
+	"testing"
+)
+
+func buildTestGraph(t *testing.T, files map[string]string) *TaskGraph {
+	t.Helper()
+	root := t.TempDir()
+	writeTaskFiles(t, root, files)
+
+	store, err := NewFSTaskStore(root, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := BuildTaskGraph(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestTaskGraph_TopologicalOrder(t *testing.T) {
+	g := buildTestGraph(t, map[string]string{
+		"01_base.md":           "# Base",
+		"01_base_completed.md": "done",
+		"02_api.md":            "DEPENDS_ON: 03, 01",
+		"03_models.md":         "DEPENDS_ON: 01",
+		"04_docs.md":           "# Docs",
+	})
+
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(order, ","); got != "01,03,02,04" {
+		t.Errorf("unexpected order: %s", got)
+	}
+
+	if !g.Nodes["01"].Completed {
+		t.Error("task 01 should be completed")
+	}
+
+	if got := g.BlockedBy("02"); strings.Join(got, ",") != "03" {
+		t.Errorf("task 02 should be blocked by 03, got %v", got)
+	}
+}
+
+func TestTaskGraph_CyclesAndMissing(t *testing.T) {
+	g := buildTestGraph(t, map[string]string{
+		"01_a.md": "DEPENDS_ON: 03",
+		"02_b.md": "DEPENDS_ON: 01",
+		"03_c.md": "DEPENDS_ON: 02, 09",
+	})
+
+	cycles := g.Cycles()
+	if len(cycles) != 1 {
+		t.Fatalf("expected 1 cycle, got %v", cycles)
+	}
+	if got := strings.Join(cycles[0], " -> "); got != "01 -> 03 -> 02 -> 01" {
+		t.Errorf("unexpected cycle: %s", got)
+	}
+
+	if _, err := g.TopologicalOrder(); err == nil {
+		t.Error("TopologicalOrder should fail on cycles")
+	}
+
+	if got := g.Missing["03"]; len(got) != 1 || got[0] != "09" {
+		t.Errorf("expected missing dependency 09, got %v", got)
+	}
+}
+
+func TestTaskGraph_NestedDependencies(t *testing.T) {
+	g := buildTestGraph(t, map[string]string{
+		"auth/01_model.md": "# Model",
+		"auth/02_login.md": "DEPENDS_ON: 01",
+		"web/01_page.md":   "DEPENDS_ON: auth/02",
+	})
+
+	if got := g.Nodes["auth/02"].DependsOn; len(got) != 1 || got[0] != "auth/01" {
+		t.Errorf("relative dependency should resolve to auth/01, got %v", got)
+	}
+
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(order, ","); got != "auth/01,auth/02,web/01" {
+		t.Errorf("unexpected order: %s", got)
+	}
+}
+
+func TestTaskGraph_Render(t *testing.T) {
+	g := buildTestGraph(t, map[string]string{
+		"01_base.md":           "# Base",
+		"01_base_completed.md": "done",
+		"auth/02_login.md":     "# Login\nDEPENDS_ON: 01, 07",
+	})
+
+	mermaid := g.Mermaid()
+	for _, want := range []string{
+		"graph TD\n",
+		`t_01["01: Base ✓"]`,
+		"t_01 --> t_auth_02",
+		`missing_t_07["07 (missing)"] -.-> t_auth_02`,
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid output missing %q:\n%s", want, mermaid)
+		}
+	}
+
+	dot := g.DOT()
+	for _, want := range []string{
+		`"01" [label="01: Base", style=filled, fillcolor=palegreen];`,
+		`"01" -> "auth/02";`,
+		`"07" -> "auth/02" [style=dashed];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+}
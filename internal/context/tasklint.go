@@ -0,0 +1,117 @@
+package aicontext
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/prompt"
+)
+
+// Sections every task document must have
+var requiredSections = []string{"Objective", "Requirements"}
+
+// "- path # new" or "- path # create" marks a file the task will create
+var newFileMarker = regexp.MustCompile(`(?i)\b(new|creates?|created)\b`)
+
+type LintIssue struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s:%d: %s", i.Path, i.Line, i.Message)
+}
+
+// LintTasks validates every task document, resolving target files against repoRoot
+func LintTasks(store TaskStore, repoRoot string) ([]LintIssue, error) {
+	graph, err := BuildTaskGraph(store)
+	if err != nil {
+		return nil, err
+	}
+
+	inCycle := make(map[string]bool)
+	for _, cycle := range graph.Cycles() {
+		for _, id := range cycle {
+			inCycle[id] = true
+		}
+	}
+
+	var issues []LintIssue
+	for _, id := range graph.IDs {
+		node := graph.Nodes[id]
+		issues = append(issues, lintTask(store, graph, node, repoRoot, inCycle[id])...)
+	}
+
+	return issues, nil
+}
+
+func lintTask(store TaskStore, graph *TaskGraph, node *TaskNode, repoRoot string, inCycle bool) []LintIssue {
+	var issues []LintIssue
+	report := func(line int, format string, args ...any) {
+		issues = append(issues, LintIssue{Path: node.Path, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	missing := make(map[string]bool)
+	for _, dep := range graph.Missing[node.ID] {
+		missing[dep] = true
+	}
+
+	// Metadata is parsed the way the coder reads it; only headings are scanned here
+	meta := node.Metadata
+
+	if meta.LanguageLine > 0 {
+		if meta.Language == "" {
+			report(meta.LanguageLine, "empty LANGUAGE")
+		} else if prompt.ParseLanguage(meta.Language) == prompt.LangUnknown && store.LanguageDoc(meta.Language) == "" {
+			report(meta.LanguageLine, "unknown LANGUAGE %q (no built-in prompt or languages/%s.md)", meta.Language, meta.Language)
+		}
+	}
+
+	if meta.DependsOnLine > 0 {
+		for _, dep := range meta.DependsOn {
+			if missing[dep] {
+				report(meta.DependsOnLine, "DEPENDS_ON %q has no task file", dep)
+			}
+		}
+		if inCycle {
+			report(meta.DependsOnLine, "task %s is part of a dependency cycle", node.ID)
+		}
+	}
+
+	for _, target := range meta.Targets {
+		if newFileMarker.MatchString(target.Comment) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(repoRoot, filepath.FromSlash(target.Path))); err != nil {
+			report(target.Line, "target file %s does not exist (mark it with '# new' if the task creates it)", target.Path)
+		}
+	}
+
+	sections := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(meta.Content))
+	for scanner.Scan() {
+		trimmed := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		heading := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+		for _, section := range requiredSections {
+			if strings.HasPrefix(strings.ToLower(heading), strings.ToLower(section)) {
+				sections[section] = true
+			}
+		}
+	}
+
+	for _, section := range requiredSections {
+		if !sections[section] {
+			report(1, "missing ## %s section", section)
+		}
+	}
+
+	return issues
+}
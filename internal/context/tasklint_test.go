@@ -0,0 +1,91 @@
+package aicontext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintTasks(t *testing.T) {
+	repo := t.TempDir()
+	writeTaskFiles(t, repo, map[string]string{
+		"exists.go": "package main",
+	})
+
+	root := t.TempDir()
+	writeTaskFiles(t, root, map[string]string{
+		"01_ok.md": `# Task 01
+LANGUAGE: go
+TARGET FILES:
+- exists.go
+- created.go # new
+
+## Objective
+Do it.
+
+## Requirements
+- It works
+`,
+		"02_bad.md": `# Task 02
+LANGUAGE: cobol
+DEPENDS_ON: 01, 09
+TARGET FILES:
+- ` + "`missing.go`" + `
+
+## Objective
+Do it.
+`,
+		"03_custom.md":      "LANGUAGE: rust\n## Objective\n## Requirements\n",
+		"04_blank.md":       "TARGET FILES:\n\n- gone.go\n## Objective\n## Requirements\n",
+		"languages/rust.md": "rust standards",
+	})
+
+	store, err := NewFSTaskStore(root, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := LintTasks(store, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, issue := range issues {
+		path := strings.TrimPrefix(issue.Path, root+"/")
+		got = append(got, strings.Replace(issue.String(), issue.Path, path, 1))
+	}
+
+	expected := []string{
+		`02_bad.md:2: unknown LANGUAGE "cobol" (no built-in prompt or languages/cobol.md)`,
+		`02_bad.md:3: DEPENDS_ON "09" has no task file`,
+		`02_bad.md:5: target file missing.go does not exist (mark it with '# new' if the task creates it)`,
+		`02_bad.md:1: missing ## Requirements section`,
+		`04_blank.md:3: target file gone.go does not exist (mark it with '# new' if the task creates it)`,
+	}
+
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected issues:\n%s\n\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestLintTasks_Cycle(t *testing.T) {
+	root := t.TempDir()
+	writeTaskFiles(t, root, map[string]string{
+		"01_a.md": "DEPENDS_ON: 02\n## Objective\n## Requirements\n",
+		"02_b.md": "DEPENDS_ON: 01\n## Objective\n## Requirements\n",
+	})
+
+	store, err := NewFSTaskStore(root, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := LintTasks(store, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 2 || !strings.Contains(issues[0].Message, "dependency cycle") {
+		t.Errorf("expected a cycle issue per task, got %v", issues)
+	}
+}
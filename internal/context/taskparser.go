@@ -6,10 +6,23 @@ import (
 )
 
 type TaskMetadata struct {
-	TargetFiles []string // Files to include with full content
-	Content     string   // Full task content
+	Title       string       // First level-1 heading
+	TargetFiles []string     // Files to include with full content
+	Targets     []TargetFile // TargetFiles with their position in Content
+	Content     string       // Full task content
 	DependsOn   []string
 	Language    string // Language if specified
+
+	// 1-based lines of the last LANGUAGE and DEPENDS_ON, 0 if absent
+	LanguageLine  int
+	DependsOnLine int
+}
+
+// TargetFile is an entry of the TARGET FILES list
+type TargetFile struct {
+	Path    string
+	Line    int    // 1-based line in Content
+	Comment string // Text after "#", e.g. "new"
 }
 
 func ParseTaskMetadata(taskContent string) *TaskMetadata {
@@ -19,20 +32,28 @@ func ParseTaskMetadata(taskContent string) *TaskMetadata {
 
 	scanner := bufio.NewScanner(strings.NewReader(taskContent))
 	inTargetFiles := false
+	lineNum := 0
 
 	for scanner.Scan() {
+		lineNum++
 		line := scanner.Text()
 		trimmed := strings.TrimSpace(line)
 
+		if meta.Title == "" && strings.HasPrefix(trimmed, "# ") {
+			meta.Title = strings.TrimSpace(trimmed[2:])
+		}
+
 		// Detect language
 		if strings.HasPrefix(strings.ToUpper(trimmed), "LANGUAGE:") {
 			meta.Language = strings.TrimSpace(trimmed[9:]) // len("LANGUAGE:") = 9
+			meta.LanguageLine = lineNum
 			continue
 		}
 
 		// Detect dependency files
 		if strings.HasPrefix(strings.ToUpper(trimmed), "DEPENDS_ON:") {
 			value := strings.TrimSpace(trimmed[11:]) // len("DEPENDS_ON:") = 11
+			meta.DependsOnLine = lineNum
 
 			targets := strings.Split(value, ",")
 			for _, file := range targets {
@@ -60,13 +81,15 @@ func ParseTaskMetadata(taskContent string) *TaskMetadata {
 			if strings.HasPrefix(trimmed, "-") {
 				path := strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
 				// Remove comments
+				comment := ""
 				if idx := strings.Index(path, "#"); idx > 0 {
-					path = strings.TrimSpace(path[:idx])
+					path, comment = strings.TrimSpace(path[:idx]), strings.TrimSpace(path[idx+1:])
 				}
 				// Remove backticks
 				path = strings.Trim(path, "`")
 				if path != "" {
 					meta.TargetFiles = append(meta.TargetFiles, path)
+					meta.Targets = append(meta.Targets, TargetFile{Path: path, Line: lineNum, Comment: comment})
 				}
 			}
 
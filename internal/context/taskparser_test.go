@@ -1,6 +1,7 @@
 package aicontext
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -119,6 +120,18 @@ Add authentication.`,
 	}
 }
 
+func TestParseTaskMetadata_Lines(t *testing.T) {
+	meta := ParseTaskMetadata("# Task\nLANGUAGE: go\nDEPENDS_ON: 01\nTARGET FILES:\n\n- a.go\n- b.go # new\n")
+
+	expected := []TargetFile{{Path: "a.go", Line: 6}, {Path: "b.go", Line: 7, Comment: "new"}}
+	if !reflect.DeepEqual(meta.Targets, expected) {
+		t.Errorf("Targets = %+v, want %+v", meta.Targets, expected)
+	}
+	if meta.LanguageLine != 2 || meta.DependsOnLine != 3 {
+		t.Errorf("LanguageLine = %d, DependsOnLine = %d", meta.LanguageLine, meta.DependsOnLine)
+	}
+}
+
 func TestParseTaskMetadata_Language(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -186,6 +199,21 @@ Some content here.`
 	}
 }
 
+func TestParseTaskMetadata_Title(t *testing.T) {
+	input := `LANGUAGE: go
+
+# Task 01: Setup
+
+## Objective
+# Not the title`
+
+	meta := ParseTaskMetadata(input)
+
+	if meta.Title != "Task 01: Setup" {
+		t.Errorf("expected title %q, got %q", "Task 01: Setup", meta.Title)
+	}
+}
+
 func TestIsTargetFile(t *testing.T) {
 	meta := &TaskMetadata{
 		TargetFiles: []string{
@@ -0,0 +1,296 @@
+package aicontext
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultTasksDir        = "docs/tasks"
+	DefaultOverviewFile    = "00_overview.md"
+	DefaultCompletedSuffix = "_completed"
+	DefaultIDPattern       = `^(\d+)_`
+
+	languagesDir = "languages"
+)
+
+// TaskStore loads task documents
+type TaskStore interface {
+	Overview() string
+	OverviewPath() string
+	Instruction(taskID string) (string, error)
+	Completed(taskID string) (string, error)
+	CompletedPath(taskID string) (string, error)
+	LanguageDoc(lang string) string
+	Tasks() ([]TaskFile, error)
+}
+
+type TaskFile struct {
+	ID            string // "03", or "auth/03" for nested tasks
+	Path          string // Instruction file
+	CompletedPath string // Empty if not completed
+}
+
+// FSTaskStore reads tasks from a directory tree
+//
+//	{Root}/{OverviewFile}
+//	{Root}/[epic/]{ID}_name.md
+//	{Root}/[epic/]{ID}_name{CompletedSuffix}.md
+//	{Root}/languages/{lang}.md
+type FSTaskStore struct {
+	Root            string
+	OverviewFile    string
+	CompletedSuffix string
+	IDPattern       *regexp.Regexp // First capture group is the task ID
+}
+
+func NewFSTaskStore(root, overviewFile, completedSuffix, idPattern string) (*FSTaskStore, error) {
+	if root == "" {
+		root = DefaultTasksDir
+	}
+	if overviewFile == "" {
+		overviewFile = DefaultOverviewFile
+	}
+	if completedSuffix == "" {
+		completedSuffix = DefaultCompletedSuffix
+	}
+	if idPattern == "" {
+		idPattern = DefaultIDPattern
+	}
+
+	re, err := regexp.Compile(idPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task ID pattern %q: %w", idPattern, err)
+	}
+	if re.NumSubexp() < 1 {
+		return nil, fmt.Errorf("task ID pattern %q must have a capture group", idPattern)
+	}
+
+	return &FSTaskStore{
+		Root:            filepath.Clean(root),
+		OverviewFile:    overviewFile,
+		CompletedSuffix: completedSuffix,
+		IDPattern:       re,
+	}, nil
+}
+
+func (s *FSTaskStore) OverviewPath() string {
+	return filepath.Join(s.Root, s.OverviewFile)
+}
+
+func (s *FSTaskStore) Overview() string {
+	content, err := loadFile(s.OverviewPath())
+	if err != nil {
+		return ""
+	}
+
+	return content
+}
+
+// {Root}/[dir/]{ID}_*.md (exclude completed docs)
+func (s *FSTaskStore) Instruction(taskID string) (string, error) {
+	// Task given as a file path (e.g. auth/03_login.md)
+	if strings.HasSuffix(taskID, ".md") {
+		return loadFile(filepath.Join(s.Root, filepath.FromSlash(taskID)))
+	}
+
+	taskID = s.resolveID(taskID)
+	files := s.findTaskFiles(taskID, false)
+	if len(files) == 0 {
+		return "", fmt.Errorf("no instruction file found for Task %s", taskID)
+	}
+
+	// XXX: Support multiple instruction files
+	return loadFile(files[0])
+}
+
+// {Root}/[dir/]{ID}_*{CompletedSuffix}.md
+func (s *FSTaskStore) Completed(taskID string) (string, error) {
+	taskID = s.resolveID(taskID)
+	files := s.findTaskFiles(taskID, true)
+	if len(files) == 0 {
+		return "", fmt.Errorf("no completed doc found for Task %s", taskID)
+	}
+
+	return loadFile(files[0])
+}
+
+// CompletedPath is where the completion summary of a task is written
+func (s *FSTaskStore) CompletedPath(taskID string) (string, error) {
+	taskID = s.resolveID(taskID)
+	if files := s.findTaskFiles(taskID, true); len(files) > 0 {
+		return files[0], nil
+	}
+
+	files := s.findTaskFiles(taskID, false)
+	if len(files) == 0 {
+		return "", fmt.Errorf("no instruction file found for Task %s", taskID)
+	}
+
+	return strings.TrimSuffix(files[0], ".md") + s.CompletedSuffix + ".md", nil
+}
+
+// {Root}/languages/{lang}.md
+func (s *FSTaskStore) LanguageDoc(lang string) string {
+	content, err := loadFile(filepath.Join(s.Root, languagesDir, lang+".md"))
+	if err != nil {
+		return ""
+	}
+
+	return content
+}
+
+// Tasks lists all instruction files sorted by ID
+func (s *FSTaskStore) Tasks() ([]TaskFile, error) {
+	byID := make(map[string]*TaskFile)
+
+	err := filepath.WalkDir(s.Root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if p != s.Root && d.Name() == languagesDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		id, completed, ok := s.parseFile(p)
+		if !ok {
+			return nil
+		}
+
+		task, exists := byID[id]
+		if !exists {
+			task = &TaskFile{ID: id}
+			byID[id] = task
+		}
+
+		if completed {
+			task.CompletedPath = p
+		} else if task.Path == "" {
+			task.Path = p
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks in %s: %w", s.Root, err)
+	}
+
+	var tasks []TaskFile
+	for _, task := range byID {
+		if task.Path == "" {
+			continue // Completed doc without instructions
+		}
+		tasks = append(tasks, *task)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	return tasks, nil
+}
+
+// findTaskFiles returns task files in the task's directory matching its ID
+func (s *FSTaskStore) findTaskFiles(taskID string, completed bool) []string {
+	dir, _ := splitTaskID(taskID)
+
+	entries, err := os.ReadDir(filepath.Join(s.Root, filepath.FromSlash(dir)))
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		p := filepath.Join(s.Root, filepath.FromSlash(dir), e.Name())
+		id, isCompleted, ok := s.parseFile(p)
+		if !ok || id != taskID || isCompleted != completed {
+			continue
+		}
+
+		files = append(files, p)
+	}
+
+	sort.Strings(files)
+	return files
+}
+
+// resolveID returns the task ID of a task given by ID or file path
+// (e.g. auth/03_login.md), relative to the root
+func (s *FSTaskStore) resolveID(taskID string) string {
+	taskID = normalizeTaskID(taskID)
+	if strings.HasSuffix(taskID, ".md") {
+		if id, _, ok := s.parseFile(filepath.Join(s.Root, filepath.FromSlash(taskID))); ok {
+			return id
+		}
+	}
+	return taskID
+}
+
+// parseFile extracts the task ID of a task file; the overview is not a task
+func (s *FSTaskStore) parseFile(p string) (id string, completed bool, ok bool) {
+	if filepath.Ext(p) != ".md" || filepath.Clean(p) == s.OverviewPath() {
+		return "", false, false
+	}
+
+	base := strings.TrimSuffix(filepath.Base(p), ".md")
+	m := s.IDPattern.FindStringSubmatch(base)
+	if m == nil || m[1] == "" {
+		return "", false, false
+	}
+
+	rel, err := filepath.Rel(s.Root, filepath.Dir(p))
+	if err != nil {
+		return "", false, false
+	}
+
+	id = m[1]
+	if rel != "." {
+		id = path.Join(filepath.ToSlash(rel), id)
+	}
+
+	return id, strings.HasSuffix(base, s.CompletedSuffix), true
+}
+
+// normalizeTaskID turns " ./auth/03/" into "auth/03"
+func normalizeTaskID(taskID string) string {
+	taskID = strings.Trim(filepath.ToSlash(strings.TrimSpace(taskID)), "/")
+	if taskID == "" {
+		return ""
+	}
+	return path.Clean(taskID)
+}
+
+// dependencyIDs are the task IDs a DEPENDS_ON entry of a task can mean, in
+// order: the entry itself, then relative to the task's directory (auth/03
+// depending on "02" can mean auth/02)
+func dependencyIDs(taskID, dep string) []string {
+	dep = normalizeTaskID(dep)
+	ids := []string{dep}
+	if dir, _ := splitTaskID(normalizeTaskID(taskID)); dir != "" {
+		ids = append(ids, path.Join(dir, dep))
+	}
+	return ids
+}
+
+// splitTaskID splits "auth/03" into ("auth", "03")
+func splitTaskID(taskID string) (string, string) {
+	taskID = strings.Trim(filepath.ToSlash(taskID), "/")
+	if idx := strings.LastIndex(taskID, "/"); idx >= 0 {
+		return taskID[:idx], taskID[idx+1:]
+	}
+	return "", taskID
+}
@@ -0,0 +1,172 @@
+package aicontext
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTaskFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFSTaskStore_Default(t *testing.T) {
+	root := t.TempDir()
+	writeTaskFiles(t, root, map[string]string{
+		"00_overview.md":           "overview",
+		"01_setup.md":              "setup instructions",
+		"01_setup_completed.md":    "setup done",
+		"02_auth.md":               "auth instructions",
+		"languages/go.md":          "go standards",
+		"languages/01_not_task.md": "ignored",
+	})
+
+	store, err := NewFSTaskStore(root, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := store.Overview(); got != "overview" {
+		t.Errorf("Overview() = %q", got)
+	}
+
+	if got, err := store.Instruction("01"); err != nil || got != "setup instructions" {
+		t.Errorf("Instruction(01) = %q, %v", got, err)
+	}
+
+	if got, err := store.Completed("01"); err != nil || got != "setup done" {
+		t.Errorf("Completed(01) = %q, %v", got, err)
+	}
+
+	if _, err := store.Completed("02"); err == nil {
+		t.Error("Completed(02) should fail")
+	}
+
+	if _, err := store.Instruction("03"); err == nil {
+		t.Error("Instruction(03) should fail")
+	}
+
+	// The overview matches the ID pattern but is not a task
+	if _, err := store.Instruction("00"); err == nil {
+		t.Error("Instruction(00) should not load the overview")
+	}
+
+	if got := store.LanguageDoc("go"); got != "go standards" {
+		t.Errorf("LanguageDoc(go) = %q", got)
+	}
+
+	tasks, err := store.Tasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d: %v", len(tasks), tasks)
+	}
+	if tasks[0].ID != "01" || tasks[0].CompletedPath == "" {
+		t.Errorf("task 01 should be completed: %+v", tasks[0])
+	}
+	if tasks[1].ID != "02" || tasks[1].CompletedPath != "" {
+		t.Errorf("task 02 should be pending: %+v", tasks[1])
+	}
+}
+
+func TestFSTaskStore_NestedCustomLayout(t *testing.T) {
+	root := t.TempDir()
+	writeTaskFiles(t, root, map[string]string{
+		"README.md":              "overview",
+		"auth/T03-login.md":      "login instructions",
+		"auth/T03-login.done.md": "login done",
+		"billing/T03-invoice.md": "invoice instructions",
+	})
+
+	store, err := NewFSTaskStore(root, "README.md", ".done", `^T(\d+)-`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := store.Overview(); got != "overview" {
+		t.Errorf("Overview() = %q", got)
+	}
+
+	if got, err := store.Instruction("auth/03"); err != nil || got != "login instructions" {
+		t.Errorf("Instruction(auth/03) = %q, %v", got, err)
+	}
+
+	if got, err := store.Instruction("billing/03"); err != nil || got != "invoice instructions" {
+		t.Errorf("Instruction(billing/03) = %q, %v", got, err)
+	}
+
+	if got, err := store.Instruction("auth/T03-login.md"); err != nil || got != "login instructions" {
+		t.Errorf("Instruction by path = %q, %v", got, err)
+	}
+
+	if got, err := store.Completed("auth/03"); err != nil || got != "login done" {
+		t.Errorf("Completed(auth/03) = %q, %v", got, err)
+	}
+
+	// Every ID form that loads the instructions finds the completed doc
+	for _, id := range []string{"auth/T03-login.md", "/auth/03/", "./auth/03"} {
+		if got, err := store.Completed(id); err != nil || got != "login done" {
+			t.Errorf("Completed(%s) = %q, %v", id, got, err)
+		}
+	}
+	want := filepath.Join(root, "billing", "T03-invoice.done.md")
+	if got, err := store.CompletedPath("billing/T03-invoice.md"); err != nil || got != want {
+		t.Errorf("CompletedPath by path = %q, %v, want %q", got, err, want)
+	}
+
+	tasks, err := store.Tasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	if strings.Join(ids, ",") != "auth/03,billing/03" {
+		t.Errorf("unexpected task IDs: %v", ids)
+	}
+}
+
+func TestGetDependentContext_Nested(t *testing.T) {
+	root := t.TempDir()
+	writeTaskFiles(t, root, map[string]string{
+		"auth/02_session.md":           "session instructions",
+		"auth/02_session_completed.md": "session done",
+		"01_setup.md":                  "setup instructions",
+	})
+	store, err := NewFSTaskStore(root, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer SetTaskStore(GetTaskStore())
+	SetTaskStore(store)
+
+	// Dependencies resolve like the task graph: exact ID, then relative to the task
+	got := GetDependentContext("auth/03", []string{"02", "01"})
+	for _, want := range []string{"--- Task auth/02 (Completed) ---\nsession done", "--- Task 01 (Instructions Only) ---\nsetup instructions"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestNewFSTaskStore_InvalidPattern(t *testing.T) {
+	if _, err := NewFSTaskStore("", "", "", `^\d+_`); err == nil {
+		t.Error("pattern without capture group should fail")
+	}
+	if _, err := NewFSTaskStore("", "", "", `(`); err == nil {
+		t.Error("invalid regex should fail")
+	}
+}
@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"strings"
+)
+
+const utf8BOM = "\uFEFF"
+
+// Conventions of an existing file that generated content should keep
+type Conventions struct {
+	FinalNewline bool
+	CRLF         bool
+	BOM          bool
+	IndentTabs   bool // Indented with tabs rather than spaces
+	IndentWidth  int  // Spaces per level, 0 if not indented with spaces
+}
+
+// DefaultConventions apply to new files
+var DefaultConventions = Conventions{FinalNewline: true}
+
+func DetectConventions(content string) Conventions {
+	c := Conventions{BOM: strings.HasPrefix(content, utf8BOM)}
+	content = strings.TrimPrefix(content, utf8BOM)
+
+	c.FinalNewline = strings.HasSuffix(content, "\n")
+	crlf := strings.Count(content, "\r\n")
+	c.CRLF = crlf > 0 && crlf >= strings.Count(content, "\n")-crlf
+	c.IndentTabs, c.IndentWidth = detectIndent(content)
+
+	return c
+}
+
+// detectIndent reports whether most indented lines start with a tab, and
+// otherwise the indent width of the space-indented ones
+func detectIndent(content string) (bool, int) {
+	tabs, spaces, width := 0, 0, 0
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		switch line[0] {
+		case '\t':
+			tabs++
+		case ' ':
+			spaces++
+			// Odd indents are alignment (e.g. " * " in block comments), not levels
+			if n := len(line) - len(strings.TrimLeft(line, " ")); n%2 == 0 {
+				width = gcd(width, n)
+			}
+		}
+	}
+
+	switch {
+	case tabs > spaces:
+		return true, 0
+	case spaces == 0:
+		return false, 0
+	case width == 0:
+		return false, 4
+	}
+	return false, min(width, 8)
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Apply rewrites content to the conventions. Indentation is only converted
+// between tabs and spaces, never between space widths.
+func (c Conventions) Apply(content string) string {
+	content = strings.TrimPrefix(content, utf8BOM)
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = c.reindent(content)
+
+	if c.FinalNewline {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+	} else {
+		content = strings.TrimRight(content, "\n")
+	}
+
+	if c.CRLF {
+		content = strings.ReplaceAll(content, "\n", "\r\n")
+	}
+	if c.BOM {
+		content = utf8BOM + content
+	}
+	return content
+}
+
+func (c Conventions) reindent(content string) string {
+	tabs, width := detectIndent(content)
+
+	var convert func(line string) string
+	switch {
+	case c.IndentTabs && !tabs && width > 0:
+		convert = func(line string) string {
+			n := len(line) - len(strings.TrimLeft(line, " "))
+			return strings.Repeat("\t", n/width) + strings.Repeat(" ", n%width) + line[n:]
+		}
+	case !c.IndentTabs && c.IndentWidth > 0 && tabs:
+		convert = func(line string) string {
+			n := len(line) - len(strings.TrimLeft(line, "\t"))
+			return strings.Repeat(" ", n*c.IndentWidth) + line[n:]
+		}
+	default:
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = convert(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// KeepConventions rewrites each file to the conventions of the file it replaces,
+// read through the sandbox: for a rename target, the file it was renamed from.
+// New files get the defaults. Binary files are left alone.
+func KeepConventions(sandbox *Sandbox, files map[string]string, renames Renames) error {
+	for path, content := range files {
+		original, exists, err := sandbox.ReadFile(path)
+		if origin, renamed := renames[path]; err == nil && !exists && renamed {
+			original, exists, err = sandbox.ReadFile(origin)
+		}
+
+		switch {
+		case err != nil:
+			return err
+		case !exists:
+			files[path] = DefaultConventions.Apply(content)
+		case strings.ContainsRune(original, 0):
+		default:
+			files[path] = DetectConventions(original).Apply(content)
+		}
+	}
+	return nil
+}
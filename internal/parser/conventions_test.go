@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConventions_Apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		content  string
+		expected string
+	}{
+		{"final newline kept", "a\n", "b\n", "b\n"},
+		{"final newline added", "a\n", "b", "b\n"},
+		{"no final newline", "a", "b\n", "b"},
+		{"crlf", "a\r\nb\r\n", "c\nd\n", "c\r\nd\r\n"},
+		{"lf", "a\nb\n", "c\r\nd\r\n", "c\nd\n"},
+		{"bom", "\uFEFFa\n", "b\n", "\uFEFFb\n"},
+		{"bom dropped", "a\n", "\uFEFFb\n", "b\n"},
+		{"spaces to tabs", "func a() {\n\tif x {\n\t\ty()\n\t}\n}\n", "func a() {\n    if x {\n        y()\n    }\n}\n", "func a() {\n\tif x {\n\t\ty()\n\t}\n}\n"},
+		{"tabs to spaces", "a:\n  b:\n    c: 1\n", "a:\n\tb:\n\t\tc: 2\n", "a:\n  b:\n    c: 2\n"},
+		{"space widths untouched", "a:\n  b: 1\n", "a:\n    b: 2\n", "a:\n    b: 2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectConventions(tt.original).Apply(tt.content); got != tt.expected {
+				t.Errorf("Apply() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetectConventions_Indent(t *testing.T) {
+	// Block comment alignment does not make a tab-indented file space-indented
+	c := DetectConventions("/*\n * doc\n */\nfunc a() {\n\tb()\n\tc()\n\td()\n}\n")
+	if !c.IndentTabs {
+		t.Errorf("expected tabs, got %+v", c)
+	}
+
+	c = DetectConventions("def a():\n    if b:\n        c()\n")
+	if c.IndentTabs || c.IndentWidth != 4 {
+		t.Errorf("expected 4 spaces, got %+v", c)
+	}
+}
+
+func TestKeepConventions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "win.txt"), []byte("old\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"win.txt": "new\n",
+		"n.txt":   "created",
+	}
+	if err := KeepConventions(testSandbox(t, dir), files, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if files["win.txt"] != "new\r\n" {
+		t.Errorf("existing = %q", files["win.txt"])
+	}
+	if files["n.txt"] != "created\n" {
+		t.Errorf("new file = %q", files["n.txt"])
+	}
+}
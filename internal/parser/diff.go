@@ -0,0 +1,311 @@
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	diffContext = 3
+	// Beyond this many changed lines, diff as full replacement. The trace for
+	// the walk back grows with its square (about 8 MB at 1000).
+	maxEditCost = 1000
+)
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	text string
+}
+
+// DiffFiles renders a git-apply compatible patch of files against the disk,
+// read through the sandbox. A rename target is created with the mode of the
+// file it was renamed from.
+func DiffFiles(sandbox *Sandbox, files map[string]string, renames Renames) (string, error) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		before, exists, err := sandbox.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		mode, err := createMode(sandbox, path, renames)
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(UnifiedDiff(path, before, files[path], exists, mode))
+	}
+
+	return b.String(), nil
+}
+
+// DiffDeletes renders a git-apply compatible patch removing each file inside the sandbox
+func DiffDeletes(sandbox *Sandbox, paths []string) (string, error) {
+	var b strings.Builder
+	for _, path := range paths {
+		before, exists, err := sandbox.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			continue
+		}
+		mode, err := sandbox.mode(path)
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", path, path))
+		b.WriteString(fmt.Sprintf("deleted file mode %s\n", gitMode(mode)))
+		b.WriteString(fmt.Sprintf("--- a/%s\n", path))
+		b.WriteString("+++ /dev/null\n")
+		for _, h := range buildHunks(diffLines(splitLinesKeepEOL(before), nil)) {
+			b.WriteString(h)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// UnifiedDiff returns the diff of one file, empty if unchanged. A file that
+// does not exist yet is created with mode.
+func UnifiedDiff(path, before, after string, exists bool, mode fs.FileMode) string {
+	if exists && before == after {
+		return ""
+	}
+
+	ops := diffLines(splitLinesKeepEOL(before), splitLinesKeepEOL(after))
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", path, path))
+	if exists {
+		b.WriteString(fmt.Sprintf("--- a/%s\n", path))
+	} else {
+		// WriteFiles keeps the mode of existing files, so only new ones have one
+		b.WriteString(fmt.Sprintf("new file mode %s\n", gitMode(mode)))
+		b.WriteString("--- /dev/null\n")
+	}
+	b.WriteString(fmt.Sprintf("+++ b/%s\n", path))
+
+	for _, h := range buildHunks(ops) {
+		b.WriteString(h)
+	}
+
+	return b.String()
+}
+
+// gitMode is the git file mode of a regular file
+func gitMode(mode fs.FileMode) string {
+	if mode&0111 != 0 {
+		return "100755"
+	}
+	return "100644"
+}
+
+// splitLinesKeepEOL keeps "\n" on each line so a missing final newline is a change
+func splitLinesKeepEOL(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func buildHunks(ops []diffOp) []string {
+	var hunks []string
+
+	i := 0
+	oldLine, newLine := 1, 1
+	for i < len(ops) {
+		// Skip to the next change
+		if ops[i].kind == ' ' {
+			i++
+			oldLine++
+			newLine++
+			continue
+		}
+
+		// Extend the hunk while changes are within 2*context of each other
+		start := max(i-diffContext, 0)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+				continue
+			}
+			if j-end > 2*diffContext {
+				break
+			}
+		}
+		stop := min(end+diffContext+1, len(ops))
+
+		// Line numbers at hunk start
+		oldStart := oldLine - (i - start)
+		newStart := newLine - (i - start)
+
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		for _, op := range ops[start:stop] {
+			body.WriteByte(op.kind)
+			body.WriteString(op.text)
+			if !strings.HasSuffix(op.text, "\n") {
+				body.WriteString("\n\\ No newline at end of file\n")
+			}
+
+			switch op.kind {
+			case ' ':
+				oldCount++
+				newCount++
+			case '-':
+				oldCount++
+			case '+':
+				newCount++
+			}
+		}
+
+		// Empty side starts at the line before the hunk
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+
+		hunks = append(hunks, fmt.Sprintf("@@ -%d,%d +%d,%d @@\n%s", oldStart, oldCount, newStart, newCount, body.String()))
+
+		for _, op := range ops[i:stop] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		i = stop
+	}
+
+	return hunks
+}
+
+// diffLines computes a shortest edit script with Myers' algorithm, after
+// taking off the common prefix and suffix
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEditCost)
+
+	// V is indexed by k+offset; trace[d] holds V for k in [-(d+1), d+1] at
+	// the start of round d
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	found := -1
+
+	for d := 0; d <= limit && found < 0; d++ {
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				found = d
+				break
+			}
+		}
+	}
+
+	if found < 0 {
+		return replaceAll(a, b)
+	}
+
+	// Walk back from (n, m) to (0, 0)
+	var ops []diffOp
+	x, y := n, m
+	for d := found; d >= 0; d-- {
+		vd := trace[d]
+		get := func(k int) int { return vd[k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffOp{'+', b[y-1]})
+			} else {
+				ops = append(ops, diffOp{'-', a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	slices.Reverse(ops)
+	return ops
+}
+
+func replaceAll(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a {
+		ops = append(ops, diffOp{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, diffOp{'+', line})
+	}
+	return ops
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff_NewFile(t *testing.T) {
+	diff := UnifiedDiff("pkg/new.go", "", "package pkg\n\nfunc New() {}", false, newFileMode)
+
+	expected := `diff --git a/pkg/new.go b/pkg/new.go
+new file mode 100644
+--- /dev/null
++++ b/pkg/new.go
+@@ -0,0 +1,3 @@
++package pkg
++
++func New() {}
+\ No newline at end of file
+`
+	if diff != expected {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff_Unchanged(t *testing.T) {
+	if diff := UnifiedDiff("a.go", "same\n", "same\n", true, newFileMode); diff != "" {
+		t.Errorf("expected empty diff, got:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff_Modified(t *testing.T) {
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	after := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n"
+
+	diff := UnifiedDiff("n.txt", before, after, true, newFileMode)
+
+	expected := `diff --git a/n.txt b/n.txt
+--- a/n.txt
++++ b/n.txt
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -13,3 +13,4 @@
+ 13
+ 14
+ 15
++16
+`
+	if diff != expected {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff_MissingFinalNewline(t *testing.T) {
+	diff := UnifiedDiff("a.txt", "a\nb\n", "a\nb", true, newFileMode)
+
+	if !strings.Contains(diff, "-b\n+b\n\\ No newline at end of file\n") {
+		t.Errorf("should report the removed final newline:\n%s", diff)
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "keep.txt"), []byte("same"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	patch, err := DiffFiles(testSandbox(t, dir), map[string]string{
+		"keep.txt":    "same",
+		"sub/new.txt": "hello",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(patch, "keep.txt") {
+		t.Error("unchanged files should not appear in the patch")
+	}
+	if !strings.Contains(patch, "new file mode") || !strings.Contains(patch, "+++ b/sub/new.txt") {
+		t.Errorf("new file should be marked:\n%s", patch)
+	}
+}
+
+func TestDiffDeletes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	patch, err := DiffDeletes(testSandbox(t, dir), []string{"a.txt", "missing.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "diff --git a/a.txt b/a.txt\ndeleted file mode 100644\n--- a/a.txt\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-one\n-two\n"
+	if patch != expected {
+		t.Errorf("DiffDeletes() =\n%s\nwant:\n%s", patch, expected)
+	}
+}
+
+func TestDiffDeletes_Executable(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	patch, err := DiffDeletes(testSandbox(t, dir), []string{"run.sh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(patch, "deleted file mode 100755\n") {
+		t.Errorf("should keep the executable mode:\n%s", patch)
+	}
+}
+
+func TestDiffLines_LargeChange(t *testing.T) {
+	var a, b []string
+	for i := range 3000 {
+		a = append(a, fmt.Sprintf("a%d\n", i))
+		b = append(b, fmt.Sprintf("b%d\n", i))
+	}
+	same := []string{"head\n"}
+
+	// Past maxEditCost the middle is replaced whole, around the common lines
+	ops := diffLines(append(same, a...), append(same, b...))
+	if len(ops) != 6001 || ops[0].kind != ' ' || ops[1].kind != '-' || ops[3001].kind != '+' {
+		t.Errorf("unexpected ops: %d, first %+v", len(ops), ops[:2])
+	}
+}
@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	deleteDirective = "### Delete:"
+	renameDirective = "### Rename:"
+)
+
+// FileOp is a delete or rename directive
+type FileOp struct {
+	Path    string
+	NewPath string // Empty for a delete
+}
+
+// Renames maps the new path of each renamed file to its original path
+type Renames map[string]string
+
+// WithPartners returns paths plus the other side of every rename among them,
+// sorted, so a rename can be kept or left out as a whole
+func (r Renames) WithPartners(paths []string) []string {
+	set := make(map[string]bool)
+	for _, path := range paths {
+		set[path] = true
+	}
+	for newPath, oldPath := range r {
+		if set[newPath] || set[oldPath] {
+			set[newPath], set[oldPath] = true, true
+		}
+	}
+	return slices.Sorted(maps.Keys(set))
+}
+
+func (op FileOp) String() string {
+	if op.NewPath == "" {
+		return "delete " + op.Path
+	}
+	return fmt.Sprintf("rename %s -> %s", op.Path, op.NewPath)
+}
+
+// ParseFileOps extracts directives outside file content, in order:
+//
+//	### Delete: path/to/file
+//	### Rename: old/path -> new/path
+func ParseFileOps(response string) []FileOp {
+	var ops []FileOp
+
+	fence := ""
+	explicitEnd := "" // END FILE line of the current explicit block
+
+	for _, line := range strings.Split(response, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case explicitEnd != "":
+			if strings.HasPrefix(trimmed, endFileMarker) && strings.TrimSpace(strings.TrimPrefix(trimmed, endFileMarker)) == explicitEnd {
+				explicitEnd = ""
+			}
+		case fence != "":
+			if closesFence(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, beginFileMarker):
+			explicitEnd = strings.TrimSpace(strings.TrimPrefix(trimmed, beginFileMarker))
+		case strings.HasPrefix(trimmed, deleteDirective):
+			if path := strings.TrimSpace(strings.TrimPrefix(trimmed, deleteDirective)); path != "" {
+				ops = append(ops, FileOp{Path: path})
+			}
+		case strings.HasPrefix(trimmed, renameDirective):
+			from, to, ok := strings.Cut(strings.TrimPrefix(trimmed, renameDirective), "->")
+			from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+			if ok && from != "" && to != "" {
+				ops = append(ops, FileOp{Path: from, NewPath: to})
+			}
+		default:
+			fence = openingFence(trimmed)
+		}
+	}
+
+	return ops
+}
+
+// ApplyFileOps applies directives on top of base, reading sources through the
+// sandbox. A renamed file becomes its new path with the old content, unless base
+// already has content for it; the old path is deleted. Edits for the new path can
+// then be applied on the returned files. A rename onto an existing file fails
+// unless an earlier directive deletes it. Paths are keyed relative to the root.
+// A rejected path is returned as a *RejectedError without being read.
+func ApplyFileOps(sandbox *Sandbox, ops []FileOp, base map[string]string) (map[string]string, []string, Renames, error) {
+	files := make(map[string]string)
+	for path, content := range base {
+		files[path] = content
+	}
+
+	var deletes []string
+	renames := make(Renames)
+	for _, op := range ops {
+		path, newPath := sandbox.key(op.Path), ""
+		if op.NewPath != "" {
+			newPath = sandbox.key(op.NewPath)
+		}
+
+		content, exists := files[path]
+		if !exists {
+			target, err := sandbox.Resolve(path)
+			if err != nil {
+				return nil, nil, nil, &RejectedError{Rejections: []Rejection{{Path: op.Path, Reason: err.Error()}}}
+			}
+
+			info, err := os.Stat(target)
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				return nil, nil, nil, fmt.Errorf("failed to %s: file does not exist", op)
+			case err != nil:
+				return nil, nil, nil, fmt.Errorf("failed to %s: %w", op, err)
+			case info.IsDir():
+				return nil, nil, nil, fmt.Errorf("failed to %s: is a directory", op)
+			}
+
+			if newPath != "" {
+				data, err := os.ReadFile(target)
+				if err != nil {
+					return nil, nil, nil, fmt.Errorf("failed to %s: %w", op, err)
+				}
+				content = string(data)
+			}
+		}
+
+		// A rename may only replace a file the response deleted first
+		if _, ok := files[newPath]; newPath != "" && newPath != path && !ok && !slices.Contains(deletes, newPath) {
+			target, err := sandbox.Resolve(newPath)
+			if err != nil {
+				return nil, nil, nil, &RejectedError{Rejections: []Rejection{{Path: op.NewPath, Reason: err.Error()}}}
+			}
+			if _, err := os.Lstat(target); err == nil {
+				return nil, nil, nil, fmt.Errorf("failed to %s: target exists", op)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return nil, nil, nil, fmt.Errorf("failed to %s: %w", op, err)
+			}
+		}
+
+		delete(files, path)
+		deletes = append(removePath(deletes, path), path)
+
+		// A renamed file renamed again still comes from its original path
+		origin, renamed := renames[path]
+		if !renamed {
+			origin = path
+		}
+		delete(renames, path)
+
+		if newPath != "" {
+			if _, ok := files[newPath]; !ok {
+				files[newPath] = content
+			}
+			deletes = removePath(deletes, newPath)
+			if origin != newPath {
+				renames[newPath] = origin
+			}
+		}
+	}
+
+	return files, deletes, renames, nil
+}
+
+func removePath(paths []string, path string) []string {
+	out := paths[:0]
+	for _, p := range paths {
+		if p != path {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFileOps(t *testing.T) {
+	input := "Moving the helpers.\n\n" +
+		"### Rename: util/old.go -> util/new.go\n" +
+		"### Delete: legacy.go\n" +
+		"### File: README.md\n" +
+		"````markdown\n### Delete: inside/fence.go\n````\n" +
+		"### BEGIN FILE: docs/a.md\n### Delete: inside/marker.go\n### END FILE: docs/a.md\n" +
+		"### Rename: missing-arrow.go\n"
+
+	ops := ParseFileOps(input)
+
+	expected := []FileOp{
+		{Path: "util/old.go", NewPath: "util/new.go"},
+		{Path: "legacy.go"},
+	}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("ParseFileOps() = %v, want %v", ops, expected)
+	}
+
+	// Directive lines are not file content
+	files := ParseFiles(input)
+	if _, ok := files["util/new.go"]; ok {
+		t.Error("rename directive should not start a file")
+	}
+}
+
+func TestApplyFileOps(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"old.go", "gone.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Paths are keyed relative to the root however the directive names them
+	files, deletes, renames, err := ApplyFileOps(testSandbox(t, dir), []FileOp{
+		{Path: filepath.Join(dir, "old.go"), NewPath: "./new.go"},
+		{Path: "gone.go"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if files["new.go"] != "package x\n" || len(files) != 1 {
+		t.Errorf("unexpected files: %v", files)
+	}
+	if !reflect.DeepEqual(deletes, []string{"old.go", "gone.go"}) {
+		t.Errorf("unexpected deletes: %v", deletes)
+	}
+	if !reflect.DeepEqual(renames, Renames{"new.go": "old.go"}) {
+		t.Errorf("unexpected renames: %v", renames)
+	}
+
+	// Edits apply to the renamed file
+	files, failures := ApplyEditBlocks(testSandbox(t, dir), []EditBlock{{Path: "new.go", Search: "package x\n", Replace: "package y\n"}}, files)
+	if len(failures) != 0 || files["new.go"] != "package y\n" {
+		t.Errorf("edit after rename = %v, %v", files, failures)
+	}
+
+	_, _, _, err = ApplyFileOps(testSandbox(t, dir), []FileOp{{Path: "missing.go"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected missing file error, got %v", err)
+	}
+
+	// A source outside the root is rejected, not read
+	_, _, _, err = ApplyFileOps(testSandbox(t, dir), []FileOp{{Path: "../outside.go", NewPath: "in.go"}}, nil)
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || rejected.Rejections[0].Path != "../outside.go" {
+		t.Errorf("expected a rejected source, got %v", err)
+	}
+}
+
+func TestApplyFileOps_RenameChain(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, deletes, renames, err := ApplyFileOps(testSandbox(t, dir), []FileOp{
+		{Path: "a.go", NewPath: "b.go"},
+		{Path: "b.go", NewPath: "c.go"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 || files["c.go"] != "package a\n" {
+		t.Errorf("unexpected files: %v", files)
+	}
+	if !reflect.DeepEqual(renames, Renames{"c.go": "a.go"}) {
+		t.Errorf("a renamed file renamed again should keep its origin: %v (deletes %v)", renames, deletes)
+	}
+}
+
+func TestApplyFileOps_RenameOntoExisting(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package "+name[:1]+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, _, _, err := ApplyFileOps(testSandbox(t, dir), []FileOp{{Path: "a.go", NewPath: "b.go"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "rename a.go -> b.go: target exists") {
+		t.Errorf("expected the existing target to be refused, got %v", err)
+	}
+
+	// Deleting the target first makes the rename a replacement
+	files, deletes, _, err := ApplyFileOps(testSandbox(t, dir), []FileOp{
+		{Path: "b.go"},
+		{Path: "a.go", NewPath: "b.go"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files["b.go"] != "package a\n" || !reflect.DeepEqual(deletes, []string{"a.go"}) {
+		t.Errorf("unexpected result: %v, %v", files, deletes)
+	}
+}
+
+func TestRenames_WithPartners(t *testing.T) {
+	renames := Renames{"out/of/scope.go": "in/scope.go", "in/b.go": "in/a.go"}
+	scope := NewScope([]string{"in/"})
+
+	// Leaving out the new path of a rename leaves out its source too, so the
+	// original is not deleted with nothing written in its place
+	outside := scope.OutOfScope([]string{"out/of/scope.go", "in/scope.go", "in/b.go", "in/a.go"})
+	got := renames.WithPartners(outside)
+
+	expected := []string{"in/scope.go", "out/of/scope.go"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("WithPartners(%v) = %v, want %v", outside, got, expected)
+	}
+}
@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Coder output formats
+const (
+	FormatWhole         = "whole"          // Full content of every file
+	FormatSearchReplace = "search-replace" // SEARCH/REPLACE edit blocks
+)
+
+const (
+	searchMarker  = "<<<<<<< SEARCH"
+	dividerMarker = "======="
+	replaceMarker = ">>>>>>> REPLACE"
+)
+
+// EditBlock replaces Search with Replace in one file
+type EditBlock struct {
+	Path    string
+	Search  string
+	Replace string
+}
+
+// EditFailure is a block that could not be applied
+type EditFailure struct {
+	Block  EditBlock
+	Reason string
+}
+
+func (f EditFailure) Error() string {
+	return fmt.Sprintf("%s: %s", f.Block.Path, f.Reason)
+}
+
+// ParseEditBlocks extracts SEARCH/REPLACE blocks under "### File:" headers:
+//
+//	### File: path/to/file
+//	<<<<<<< SEARCH
+//	old lines
+//	=======
+//	new lines
+//	>>>>>>> REPLACE
+func ParseEditBlocks(response string) []EditBlock {
+	var blocks []EditBlock
+
+	var currentFile string
+	var search, replace []string
+	state := 0 // 0: outside, 1: search, 2: replace
+
+	for _, line := range strings.Split(response, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch state {
+		case 0:
+			if strings.HasPrefix(trimmed, "### File:") {
+				currentFile = strings.TrimSpace(strings.TrimPrefix(trimmed, "### File:"))
+				continue
+			}
+			if trimmed == searchMarker && currentFile != "" {
+				search, replace = nil, nil
+				state = 1
+			}
+		case 1:
+			if trimmed == dividerMarker {
+				state = 2
+				continue
+			}
+			search = append(search, line)
+		case 2:
+			if trimmed == replaceMarker {
+				blocks = append(blocks, EditBlock{
+					Path:    currentFile,
+					Search:  joinLines(search),
+					Replace: joinLines(replace),
+				})
+				state = 0
+				continue
+			}
+			replace = append(replace, line)
+		}
+	}
+
+	return blocks
+}
+
+// ApplyEditBlocks applies blocks in order on top of base, reading other files
+// through the sandbox. Files are keyed by their path relative to the root.
+// Blocks that do not match or target a rejected path are returned as failures,
+// the rest are applied.
+func ApplyEditBlocks(sandbox *Sandbox, blocks []EditBlock, base map[string]string) (map[string]string, []EditFailure) {
+	files := make(map[string]string)
+	for path, content := range base {
+		files[path] = content
+	}
+
+	var failures []EditFailure
+	for _, block := range blocks {
+		path := sandbox.key(block.Path)
+		content, exists := files[path]
+		if !exists {
+			var err error
+			if content, exists, err = sandbox.ReadFile(path); err != nil {
+				failures = append(failures, EditFailure{block, readFailure(err)})
+				continue
+			}
+		}
+
+		updated, err := applyEdit(content, exists, block)
+		if err != nil {
+			failures = append(failures, EditFailure{block, err.Error()})
+			continue
+		}
+		files[path] = updated
+	}
+
+	return files, failures
+}
+
+// readFailure is the failure reason of a file that could not be read
+func readFailure(err error) string {
+	var rej Rejection
+	if errors.As(err, &rej) {
+		return "rejected: " + rej.Reason
+	}
+	return err.Error()
+}
+
+// FailedFileContents returns the current content of the files with failed
+// blocks, for the retry prompt. Rejected paths are left out, never read.
+func FailedFileContents(sandbox *Sandbox, files map[string]string, failures []EditFailure) map[string]string {
+	current := make(map[string]string)
+	for _, f := range failures {
+		if content, ok := files[sandbox.key(f.Block.Path)]; ok {
+			current[f.Block.Path] = content
+		} else if content, exists, err := sandbox.ReadFile(f.Block.Path); err == nil && exists {
+			current[f.Block.Path] = content
+		}
+	}
+	return current
+}
+
+func applyEdit(content string, exists bool, block EditBlock) (string, error) {
+	if strings.TrimSpace(block.Search) == "" {
+		if exists && strings.TrimSpace(content) != "" {
+			return "", fmt.Errorf("empty SEARCH on a non-empty file")
+		}
+		return block.Replace, nil
+	}
+
+	if !exists {
+		return "", fmt.Errorf("file does not exist")
+	}
+
+	switch n := strings.Count(content, block.Search); n {
+	case 0:
+	case 1:
+		idx := strings.Index(content, block.Search)
+		return content[:idx] + block.Replace + content[idx+len(block.Search):], nil
+	default:
+		return "", fmt.Errorf("SEARCH text matches %d locations", n)
+	}
+
+	return applyEditLoose(content, block)
+}
+
+// applyEditLoose matches lines ignoring leading and trailing whitespace,
+// re-indenting the replacement by the indentation difference of the first line
+func applyEditLoose(content string, block EditBlock) (string, error) {
+	lines := splitLinesKeepEOL(content)
+	search := splitLinesKeepEOL(block.Search)
+
+	var matches []int
+	for i := 0; i+len(search) <= len(lines); i++ {
+		if linesMatchLoose(lines[i:i+len(search)], search) {
+			matches = append(matches, i)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("SEARCH text not found")
+	case 1:
+	default:
+		return "", fmt.Errorf("SEARCH text matches %d locations", len(matches))
+	}
+
+	start := matches[0]
+	fileIndent := leadingWhitespace(lines[start])
+	searchIndent := leadingWhitespace(search[0])
+
+	var b strings.Builder
+	for _, line := range lines[:start] {
+		b.WriteString(line)
+	}
+	for _, line := range splitLinesKeepEOL(block.Replace) {
+		if strings.TrimSpace(line) != "" && strings.HasPrefix(line, searchIndent) {
+			line = fileIndent + strings.TrimPrefix(line, searchIndent)
+		}
+		b.WriteString(line)
+	}
+	for _, line := range lines[start+len(search):] {
+		b.WriteString(line)
+	}
+
+	return b.String(), nil
+}
+
+func linesMatchLoose(a, b []string) bool {
+	for i := range a {
+		if strings.TrimSpace(a[i]) != strings.TrimSpace(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func leadingWhitespace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+// joinLines restores the newline of every line in a block
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEditBlocks(t *testing.T) {
+	input := `Changes below.
+
+### File: main.go
+` + "```go" + `
+<<<<<<< SEARCH
+func old() {}
+=======
+func renamed() {}
+>>>>>>> REPLACE
+` + "```" + `
+
+### File: new.go
+<<<<<<< SEARCH
+=======
+package main
+>>>>>>> REPLACE
+`
+
+	blocks := ParseEditBlocks(input)
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d: %+v", len(blocks), blocks)
+	}
+
+	if blocks[0].Path != "main.go" || blocks[0].Search != "func old() {}\n" || blocks[0].Replace != "func renamed() {}\n" {
+		t.Errorf("unexpected first block: %+v", blocks[0])
+	}
+	if blocks[1].Path != "new.go" || blocks[1].Search != "" || blocks[1].Replace != "package main\n" {
+		t.Errorf("unexpected second block: %+v", blocks[1])
+	}
+}
+
+func TestApplyEditBlocks_Exact(t *testing.T) {
+	base := map[string]string{
+		"a.go": "package a\n\nfunc A() int {\n\treturn 1\n}\n",
+	}
+
+	files, failures := ApplyEditBlocks(testSandbox(t, t.TempDir()), []EditBlock{
+		{Path: "a.go", Search: "\treturn 1\n", Replace: "\treturn 2\n"},
+		{Path: "a.go", Search: "package a\n", Replace: "package b\n"},
+	}, base)
+
+	if len(failures) != 0 {
+		t.Fatalf("unexpected failures: %v", failures)
+	}
+	if files["a.go"] != "package b\n\nfunc A() int {\n\treturn 2\n}\n" {
+		t.Errorf("unexpected content:\n%s", files["a.go"])
+	}
+	if base["a.go"] == files["a.go"] {
+		t.Error("base should not be modified")
+	}
+}
+
+func TestApplyEditBlocks_WhitespaceTolerant(t *testing.T) {
+	base := map[string]string{
+		"a.go": "func A() {\n\tif ok {\n\t\tdo()\n\t}\n}\n",
+	}
+
+	// Model lost the indentation and added trailing spaces
+	files, failures := ApplyEditBlocks(testSandbox(t, t.TempDir()), []EditBlock{
+		{Path: "a.go", Search: "if ok {  \n\tdo()\n}\n", Replace: "if ok {\n\tdo()\n\tmore()\n}\n"},
+	}, base)
+
+	if len(failures) != 0 {
+		t.Fatalf("unexpected failures: %v", failures)
+	}
+
+	expected := "func A() {\n\tif ok {\n\t\tdo()\n\t\tmore()\n\t}\n}\n"
+	if files["a.go"] != expected {
+		t.Errorf("unexpected content:\n%q\nexpected:\n%q", files["a.go"], expected)
+	}
+}
+
+func TestApplyEditBlocks_Failures(t *testing.T) {
+	base := map[string]string{
+		"a.go": "x := 1\ny := 2\nx := 1\n",
+	}
+
+	files, failures := ApplyEditBlocks(testSandbox(t, t.TempDir()), []EditBlock{
+		{Path: "a.go", Search: "z := 3\n", Replace: "z := 4\n"},
+		{Path: "a.go", Search: " x := 1 \n", Replace: "x := 5\n"},
+		{Path: "a.go", Search: "", Replace: "overwrite\n"},
+		{Path: "a.go", Search: "y := 2\n", Replace: "y := 3\n"},
+	}, base)
+
+	if len(failures) != 3 {
+		t.Fatalf("expected 3 failures, got %v", failures)
+	}
+	for i, want := range []string{"not found", "matches 2 locations", "empty SEARCH"} {
+		if !strings.Contains(failures[i].Reason, want) {
+			t.Errorf("failure %d = %q, want %q", i, failures[i].Reason, want)
+		}
+	}
+
+	// Other blocks still apply
+	if files["a.go"] != "x := 1\ny := 3\nx := 1\n" {
+		t.Errorf("unexpected content:\n%s", files["a.go"])
+	}
+}
+
+func TestApplyEditBlocks_DuplicateExact(t *testing.T) {
+	base := map[string]string{
+		"a.go": "func A() error {\n\treturn nil\n}\n\nfunc B() error {\n\treturn nil\n}\n",
+	}
+
+	files, failures := ApplyEditBlocks(testSandbox(t, t.TempDir()), []EditBlock{
+		{Path: "a.go", Search: "\treturn nil\n}\n", Replace: "\treturn errB\n}\n"},
+	}, base)
+
+	if len(failures) != 1 || !strings.Contains(failures[0].Reason, "matches 2 locations") {
+		t.Fatalf("expected an ambiguous match failure, got %v", failures)
+	}
+	if files["a.go"] != base["a.go"] {
+		t.Errorf("ambiguous block should not change the file:\n%s", files["a.go"])
+	}
+}
+
+func TestApplyEditBlocks_ReadsDisk(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "disk.txt")
+	if err := os.WriteFile(path, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files are keyed relative to the root, so blocks naming one file
+	// differently apply on top of each other
+	files, failures := ApplyEditBlocks(testSandbox(t, dir), []EditBlock{
+		{Path: path, Search: "hello\n", Replace: "world\n"},
+		{Path: "./disk.txt", Search: "world\n", Replace: "world!\n"},
+		{Path: filepath.Join(dir, "new.txt"), Search: "", Replace: "created\n"},
+		{Path: filepath.Join(dir, "missing.txt"), Search: "x\n", Replace: "y\n"},
+	}, nil)
+
+	if len(failures) != 1 || !strings.Contains(failures[0].Reason, "does not exist") {
+		t.Errorf("expected missing file failure, got %v", failures)
+	}
+	if !reflect.DeepEqual(files, map[string]string{"disk.txt": "world!\n", "new.txt": "created\n"}) {
+		t.Errorf("unexpected files: %v", files)
+	}
+}
+
+func TestApplyEditBlocks_RejectedPath(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "repo")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(secret, []byte("TOKEN=abc\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sandbox := testSandbox(t, root)
+
+	files, failures := ApplyEditBlocks(sandbox, []EditBlock{
+		{Path: "../secret.txt", Search: "TOKEN", Replace: "x"},
+		{Path: secret, Search: "TOKEN", Replace: "x"},
+		{Path: ".git/config", Search: "", Replace: "x\n"},
+	}, nil)
+
+	if len(failures) != 3 || len(files) != 0 {
+		t.Fatalf("expected 3 failures and no files, got %v, %v", failures, files)
+	}
+	for i, want := range []string{"rejected: outside", "rejected: outside", "rejected: protected"} {
+		if !strings.HasPrefix(failures[i].Reason, want) {
+			t.Errorf("failure %d = %q, want %q", i, failures[i].Reason, want)
+		}
+	}
+
+	// Rejected files are never read for the retry prompt
+	if current := FailedFileContents(sandbox, files, failures); len(current) != 0 {
+		t.Errorf("rejected files should not be read: %v", current)
+	}
+}
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Files shorter than this may shrink freely
+const minShrinkLines = 20
+
+// Comment markers a placeholder line starts with
+var commentPrefix = regexp.MustCompile(`^\s*(//+|#+|/\*+|\*|<!--|--|;+|%+)\s*`)
+
+// Comment text that stands in for code the model left out
+var placeholderPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(\.{3}|…)`),
+	regexp.MustCompile(`(?i)\brest of (the )?(file|code|implementation|function|class|method|module|component|test)s?\b`),
+	regexp.MustCompile(`(?i)\b(existing|previous|original|other) (code|implementation|content|logic|methods|functions|tests|imports)\b.*\b(here|unchanged|omitted|remains?|as is|as before)\b`),
+	regexp.MustCompile(`(?i)\b(code|content|implementation|unchanged parts?) (omitted|elided|truncated)\b`),
+}
+
+// Elision is a generated file that looks cut short
+type Elision struct {
+	Path   string
+	Reason string
+}
+
+func (e Elision) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// CheckElisions flags files with placeholder comments the file on disk does not
+// have, and files that lose more than maxShrink (0 to 1) of their lines; a
+// maxShrink of 0 or less disables the shrinkage check. Files are read through
+// the sandbox; rejected paths are skipped, to be refused when written.
+func CheckElisions(sandbox *Sandbox, files map[string]string, maxShrink float64) ([]Elision, error) {
+	var elisions []Elision
+	for path, content := range files {
+		original, _, err := sandbox.ReadFile(path)
+		var rej Rejection
+		switch {
+		case errors.As(err, &rej):
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		if line, text := findPlaceholder(content, original); line > 0 {
+			elisions = append(elisions, Elision{path, fmt.Sprintf("placeholder at line %d: %s", line, text)})
+			continue
+		}
+
+		before, after := countLines(original), countLines(content)
+		if maxShrink > 0 && before >= minShrinkLines && float64(before-after) > maxShrink*float64(before) {
+			elisions = append(elisions, Elision{path, fmt.Sprintf("shrinks from %d to %d lines", before, after)})
+		}
+	}
+
+	sort.Slice(elisions, func(i, j int) bool { return elisions[i].Path < elisions[j].Path })
+	return elisions, nil
+}
+
+// findPlaceholder returns the first placeholder line of content that is not in original
+func findPlaceholder(content, original string) (int, string) {
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(original, "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if existing[trimmed] {
+			continue
+		}
+
+		loc := commentPrefix.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(line[loc[1]:], "*/"), "-->"))
+		for _, p := range placeholderPatterns {
+			if p.MatchString(text) {
+				return i + 1, trimmed
+			}
+		}
+	}
+	return 0, ""
+}
+
+func countLines(s string) int {
+	if s == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(s, "\n"), "\n") + 1
+}
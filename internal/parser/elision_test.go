@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindPlaceholder(t *testing.T) {
+	tests := []struct {
+		content string
+		line    int
+	}{
+		{"func a() {\n\t// ... rest of the file unchanged ...\n}\n", 2},
+		{"def a():\n    # existing code here\n", 2},
+		{"<div>\n<!-- rest of component -->\n</div>\n", 2},
+		{"/* implementation omitted */\n", 1},
+		{"x = 1\n# ...\n", 2},
+		{"// Update existing code paths to the new API\nfunc a() {}\n", 0},
+		{"def stub():\n    ...\n", 0},
+		{"s := \"rest of the file\"\n", 0},
+	}
+
+	for _, tt := range tests {
+		if line, _ := findPlaceholder(tt.content, ""); line != tt.line {
+			t.Errorf("findPlaceholder(%q) = %d, want %d", tt.content, line, tt.line)
+		}
+	}
+
+	// A placeholder the original already has is not new
+	if line, _ := findPlaceholder("a\n// ...\n", "b\n// ...\n"); line != 0 {
+		t.Errorf("existing placeholder flagged at line %d", line)
+	}
+}
+
+func TestCheckElisions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "big.go"), []byte(strings.Repeat("x()\n", 100)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "small.go"), []byte("a\nb\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sandbox := testSandbox(t, dir)
+
+	files := map[string]string{
+		"big.go":        strings.Repeat("x()\n", 30),
+		"small.go":      "a\n",
+		"new.go":        "package x\n// ... existing code remains ...\n",
+		"fine.go":       "package x\n",
+		"../outside.go": "// ... rest of the file ...\n",
+	}
+
+	elisions, err := CheckElisions(sandbox, files, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elisions) != 2 {
+		t.Fatalf("expected 2 elisions, got %v", elisions)
+	}
+	if elisions[0].Path != "big.go" || !strings.Contains(elisions[0].Reason, "from 100 to 30 lines") {
+		t.Errorf("unexpected shrink elision: %v", elisions[0])
+	}
+	if elisions[1].Path != "new.go" || !strings.Contains(elisions[1].Reason, "line 2") {
+		t.Errorf("unexpected placeholder elision: %v", elisions[1])
+	}
+
+	// A higher threshold allows the shrink, 0 disables the check
+	for _, threshold := range []float64{0.8, 0} {
+		elisions, _ := CheckElisions(sandbox, map[string]string{"big.go": files["big.go"]}, threshold)
+		if len(elisions) != 0 {
+			t.Errorf("threshold %v: unexpected elisions %v", threshold, elisions)
+		}
+	}
+}
@@ -1,65 +1,111 @@
 package parser
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 )
 
+const (
+	fileHeader      = "### File:"
+	beginFileMarker = "### BEGIN FILE:"
+	endFileMarker   = "### END FILE:"
+)
+
+// ParseFiles extracts the files of a response. Each "### File: path" header is
+// followed by a fenced code block that ends, CommonMark style, only at a fence
+// of the same character at least as long as the opening one, so a file with its
+// own ``` fences can be wrapped in ````. Content between "### BEGIN FILE: path"
+// and "### END FILE: path" is taken as is, fences included.
 func ParseFiles(response string) map[string]string {
 	files := make(map[string]string)
 	lines := strings.Split(response, "\n")
 
 	var currentFile string
 	var codeLines []string
-	inBlock := false
+	fence := ""       // Opening fence of the current block, empty outside
+	explicit := false // Between BEGIN FILE and END FILE
+
+	save := func() {
+		if currentFile != "" && len(codeLines) > 0 {
+			files[currentFile] = blockContent(codeLines)
+		}
+		codeLines = nil
+	}
 
 	for _, line := range lines {
 		trimmed := strings.TrimSpace(line)
 
-		// Format: ### File: path/to/file
-		if strings.HasPrefix(trimmed, "### File:") {
-			// Save previous file if exists
-			if currentFile != "" && len(codeLines) > 0 {
-				files[currentFile] = strings.TrimSpace(strings.Join(codeLines, "\n"))
+		if explicit {
+			if strings.HasPrefix(trimmed, endFileMarker) && strings.TrimSpace(strings.TrimPrefix(trimmed, endFileMarker)) == currentFile {
+				save()
+				currentFile, explicit = "", false
+				continue
 			}
-			currentFile = strings.TrimSpace(strings.TrimPrefix(trimmed, "### File:"))
-			codeLines = nil
-			inBlock = false
+			codeLines = append(codeLines, line)
 			continue
 		}
 
-		// Code block
-		if strings.HasPrefix(trimmed, "```") {
-			inBlock = !inBlock
+		if fence != "" {
+			if closesFence(trimmed, fence) {
+				fence = ""
+				continue
+			}
+			if currentFile != "" {
+				codeLines = append(codeLines, line)
+			}
 			continue
 		}
 
-		if inBlock && currentFile != "" {
-			codeLines = append(codeLines, line)
+		switch {
+		case strings.HasPrefix(trimmed, beginFileMarker):
+			save()
+			currentFile = strings.TrimSpace(strings.TrimPrefix(trimmed, beginFileMarker))
+			explicit = true
+		// Format: ### File: path/to/file
+		case strings.HasPrefix(trimmed, fileHeader):
+			save()
+			currentFile = strings.TrimSpace(strings.TrimPrefix(trimmed, fileHeader))
+		default:
+			fence = openingFence(trimmed)
 		}
 	}
 
-	if currentFile != "" && len(codeLines) > 0 {
-		files[currentFile] = strings.TrimSpace(strings.Join(codeLines, "\n"))
-	}
+	save()
 
 	return files
 }
 
-func WriteFiles(files map[string]string) (int, error) {
-	count := 0
-	for path, content := range files {
-		dir := filepath.Dir(path)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return count, fmt.Errorf("failed to create directory %s: %w", dir, err)
+// openingFence returns the fence a line opens a code block with, e.g. "```" or "~~~~"
+func openingFence(trimmed string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(trimmed) - len(strings.TrimLeft(trimmed, c))
+		if n < 3 {
+			continue
 		}
-		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
-			return count, fmt.Errorf("failed to write file %s: %w", path, err)
+		// The info string of a backtick fence may not contain backticks
+		if c == "`" && strings.Contains(trimmed[n:], "`") {
+			return ""
 		}
-		fmt.Printf("Wrote: %s\n", path)
-		count++
+		return trimmed[:n]
+	}
+	return ""
+}
+
+// closesFence reports whether a line closes a block opened with fence
+func closesFence(trimmed, fence string) bool {
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// blockContent drops the blank lines models pad a block with and ends the
+// content with a newline; the file's own conventions are restored on write
+func blockContent(lines []string) string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
 	}
-	return count, nil
+	return strings.Join(lines, "\n") + "\n"
 }
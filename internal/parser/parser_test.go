@@ -133,8 +133,9 @@ func test() {}
 	if content[0] == '\n' {
 		t.Error("should trim leading newlines")
 	}
-	if content[len(content)-1] == '\n' {
-		t.Error("should trim trailing newlines")
+	// Blank padding is dropped, but the final newline is kept
+	if content != "package main\n\nfunc test() {}\n" {
+		t.Errorf("should trim blank lines and end with one newline, got %q", content)
 	}
 }
 
@@ -178,6 +179,61 @@ More explanation after.
 	}
 }
 
+func TestParseFiles_NestedFences(t *testing.T) {
+	readme := "# Usage\n\n```go\nagent.Run()\n```\n\nDone."
+	input := "### File: README.md\n" +
+		"````markdown\n" + readme + "\n````\n\n" +
+		"### File: docs/tilde.md\n" +
+		"~~~\n```\ninner\n```\n~~~\n\n" +
+		"### File: main.go\n" +
+		"```go\npackage main\n```\n"
+
+	files := ParseFiles(input)
+
+	if len(files) != 3 {
+		t.Fatalf("expected 3 files, got %d: %v", len(files), files)
+	}
+	if files["README.md"] != readme+"\n" {
+		t.Errorf("README.md = %q, want %q", files["README.md"], readme)
+	}
+	if files["docs/tilde.md"] != "```\ninner\n```\n" {
+		t.Errorf("docs/tilde.md = %q", files["docs/tilde.md"])
+	}
+	if files["main.go"] != "package main\n" {
+		t.Errorf("main.go = %q", files["main.go"])
+	}
+}
+
+func TestParseFiles_LongerClosingFence(t *testing.T) {
+	// A closing fence may be longer than the opening one, but not shorter
+	input := "### File: a.md\n````\n```\nx\n`````\n"
+
+	files := ParseFiles(input)
+
+	if files["a.md"] != "```\nx\n" {
+		t.Errorf("a.md = %q", files["a.md"])
+	}
+}
+
+func TestParseFiles_ExplicitMarkers(t *testing.T) {
+	doc := "# Task\n\n```\nunclosed fence\n### File: not/a/header.go\n### END FILE: other.md"
+	input := "Here you go.\n\n" +
+		"### BEGIN FILE: docs/tasks/01_task.md\n" + doc + "\n### END FILE: docs/tasks/01_task.md\n\n" +
+		"### File: main.go\n```go\npackage main\n```\n"
+
+	files := ParseFiles(input)
+
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d: %v", len(files), files)
+	}
+	if files["docs/tasks/01_task.md"] != doc+"\n" {
+		t.Errorf("task doc = %q, want %q", files["docs/tasks/01_task.md"], doc)
+	}
+	if files["main.go"] != "package main\n" {
+		t.Errorf("main.go = %q", files["main.go"])
+	}
+}
+
 func containsString(s, substr string) bool {
 	for i := 0; i <= len(s)-len(substr); i++ {
 		if s[i:i+len(substr)] == substr {
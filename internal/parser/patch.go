@@ -0,0 +1,317 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FormatDiff is coder output as unified diffs
+const FormatDiff = "diff"
+
+// How far from its stated position a hunk may be found
+const maxHunkOffset = 200
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\d+(?:,\d+)? @@`)
+
+// FilePatch is the unified diff of one file
+type FilePatch struct {
+	Path  string
+	New   bool // --- /dev/null
+	Hunks []Hunk
+}
+
+// Hunk lines keep their ' ', '-' or '+' prefix. Line counts in the header
+// are not checked since models often get them wrong; an old count of 0 only
+// tells that the hunk inserts after line OldStart rather than at it.
+type Hunk struct {
+	OldStart int
+	OldCount int // 1 if omitted from the header
+	Lines    []string
+}
+
+// old and new return the hunk's lines before and after the change
+func (h Hunk) old() []string { return h.side('+') }
+func (h Hunk) new() []string { return h.side('-') }
+
+// merge builds the new lines, keeping context lines as they are in the file
+func (h Hunk) merge(matched []string) []string {
+	var lines []string
+	i := 0
+	for _, line := range h.Lines {
+		switch line[0] {
+		case ' ':
+			lines = append(lines, matched[i])
+			i++
+		case '-':
+			i++
+		default:
+			lines = append(lines, line[1:])
+		}
+	}
+	return lines
+}
+
+func (h Hunk) side(skip byte) []string {
+	var lines []string
+	for _, line := range h.Lines {
+		if line[0] != skip {
+			lines = append(lines, line[1:])
+		}
+	}
+	return lines
+}
+
+// ParsePatches extracts unified diffs from a response, fenced or not
+func ParsePatches(response string) []FilePatch {
+	var patches []FilePatch
+	var current *FilePatch
+	var hunk *Hunk
+	oldPath := ""
+
+	flushHunk := func() {
+		if current != nil && hunk != nil && len(hunk.Lines) > 0 {
+			current.Hunks = append(current.Hunks, *hunk)
+		}
+		hunk = nil
+	}
+	flushFile := func() {
+		flushHunk()
+		if current != nil && len(current.Hunks) > 0 {
+			patches = append(patches, *current)
+		}
+		current = nil
+	}
+
+	lines := strings.Split(response, "\n")
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+
+		// "--- " is a file header only when followed by "+++ ", otherwise a removed "-- " line
+		isHeader := strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+
+		switch {
+		case isHeader:
+			flushFile()
+			oldPath = patchPath(line[4:])
+			continue
+		case strings.HasPrefix(line, "+++ ") && current == nil:
+			path := patchPath(line[4:])
+			if path == "" {
+				continue // Deletion, handled by file directives
+			}
+			current = &FilePatch{Path: path, New: oldPath == ""}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			flushHunk()
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			hunk = &Hunk{OldStart: start, OldCount: count}
+			continue
+		}
+
+		if hunk == nil {
+			continue
+		}
+
+		switch {
+		case line == "":
+			// Blank context line that lost its leading space
+			hunk.Lines = append(hunk.Lines, " ")
+		case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+			hunk.Lines = append(hunk.Lines, line)
+		case strings.HasPrefix(line, `\ No newline`):
+		default:
+			// Fence or prose ends the diff
+			flushFile()
+		}
+	}
+	flushFile()
+
+	// Trailing blank lines before a fence are not part of the hunk
+	for i := range patches {
+		for j := range patches[i].Hunks {
+			h := &patches[i].Hunks[j]
+			for len(h.Lines) > 0 && h.Lines[len(h.Lines)-1] == " " {
+				h.Lines = h.Lines[:len(h.Lines)-1]
+			}
+		}
+	}
+
+	return patches
+}
+
+// patchPath strips the a/ or b/ prefix, empty for /dev/null
+func patchPath(s string) string {
+	s = strings.TrimSpace(s)
+	if idx := strings.Index(s, "\t"); idx >= 0 {
+		s = s[:idx] // Timestamp
+	}
+	if s == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		return s[2:]
+	}
+	return s
+}
+
+// ApplyPatches applies each patch on top of base, reading other files through
+// the sandbox. Files are keyed by their path relative to the root. Hunks that
+// cannot be placed or target a rejected path are returned as failures in
+// edit-block form.
+func ApplyPatches(sandbox *Sandbox, patches []FilePatch, base map[string]string) (map[string]string, []EditFailure) {
+	files := make(map[string]string)
+	for path, content := range base {
+		files[path] = content
+	}
+
+	var failures []EditFailure
+	for _, p := range patches {
+		path := sandbox.key(p.Path)
+		content, exists := files[path]
+		if !exists {
+			var err error
+			if content, exists, err = sandbox.ReadFile(path); err != nil {
+				failures = append(failures, hunkFailures(p, p.Hunks, readFailure(err))...)
+				continue
+			}
+		}
+		switch {
+		case p.New && exists:
+			failures = append(failures, hunkFailures(p, p.Hunks, "file already exists")...)
+			continue
+		case !p.New && !exists:
+			failures = append(failures, hunkFailures(p, p.Hunks, "file does not exist")...)
+			continue
+		}
+
+		updated, failed := applyHunks(content, p.Hunks)
+		for _, f := range failed {
+			failures = append(failures, hunkFailures(p, []Hunk{f.hunk}, f.reason)...)
+		}
+		if len(failed) < len(p.Hunks) {
+			files[path] = updated
+		}
+	}
+
+	return files, failures
+}
+
+type hunkFailure struct {
+	hunk   Hunk
+	reason string
+}
+
+func applyHunks(content string, hunks []Hunk) (string, []hunkFailure) {
+	trailingNewline := content == "" || strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	var failures []hunkFailure
+	offset := 0 // Shift of later hunks caused by earlier ones
+	cursor := 0 // Hunks apply in order, never before the previous one
+
+	for _, h := range hunks {
+		old, replacement := h.old(), h.new()
+
+		// Index the hunk's old lines start at; "-5,0" inserts after line 5
+		stated := h.OldStart - 1
+		if len(old) == 0 && h.OldCount == 0 {
+			stated = h.OldStart
+		}
+
+		want := max(stated+offset, cursor)
+		if len(old) == 0 {
+			// Pure insertion: trust the position
+			at := min(max(want, 0), len(lines))
+			lines = splice(lines, at, 0, replacement)
+			cursor = at + len(replacement)
+			offset = at - stated + len(replacement)
+			continue
+		}
+
+		at := findHunk(lines, old, want, cursor, equalExact)
+		if at < 0 {
+			at = findHunk(lines, old, want, cursor, equalLoose)
+		}
+		if at < 0 {
+			failures = append(failures, hunkFailure{h, fmt.Sprintf("hunk @@ -%d @@ does not match", h.OldStart)})
+			continue
+		}
+
+		lines = splice(lines, at, len(old), h.merge(lines[at:at+len(old)]))
+		offset = at - stated + len(replacement) - len(old)
+		cursor = at + len(replacement)
+	}
+
+	result := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		result += "\n"
+	}
+	return result, failures
+}
+
+func equalExact(a, b string) bool { return a == b }
+
+func equalLoose(a, b string) bool { return strings.TrimSpace(a) == strings.TrimSpace(b) }
+
+// findHunk searches outward from want for old, not before cursor
+func findHunk(lines, old []string, want, cursor int, eq func(a, b string) bool) int {
+	matches := func(at int) bool {
+		if at < cursor || at+len(old) > len(lines) {
+			return false
+		}
+		for i := range old {
+			if !eq(lines[at+i], old[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for d := 0; d <= maxHunkOffset; d++ {
+		if matches(want - d) {
+			return want - d
+		}
+		if d > 0 && matches(want+d) {
+			return want + d
+		}
+	}
+	return -1
+}
+
+func splice(lines []string, at, remove int, insert []string) []string {
+	out := make([]string, 0, len(lines)-remove+len(insert))
+	out = append(out, lines[:at]...)
+	out = append(out, insert...)
+	return append(out, lines[at+remove:]...)
+}
+
+// hunkFailures describes failed hunks as SEARCH/REPLACE blocks so they can be retried
+func hunkFailures(p FilePatch, hunks []Hunk, reason string) []EditFailure {
+	var failures []EditFailure
+	for _, h := range hunks {
+		failures = append(failures, EditFailure{
+			Block: EditBlock{
+				Path:    p.Path,
+				Search:  joinLines(h.old()),
+				Replace: joinLines(h.new()),
+			},
+			Reason: reason,
+		})
+	}
+	return failures
+}
@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePatches(t *testing.T) {
+	input := `Here is the change.
+
+` + "```diff" + `
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-func old() {}
++func renamed() {}
+
+` + "```" + `
+
+--- /dev/null
++++ b/new.sql
+@@ -0,0 +1,2 @@
++-- schema
++CREATE TABLE t (id int);
+--- a/old.sql
++++ b/old.sql
+@@ -1,2 +1,1 @@
+--- obsolete
+ SELECT 1;
+`
+
+	patches := ParsePatches(input)
+	if len(patches) != 3 {
+		t.Fatalf("expected 3 patches, got %d: %+v", len(patches), patches)
+	}
+
+	if patches[0].Path != "main.go" || patches[0].New || len(patches[0].Hunks) != 1 {
+		t.Errorf("unexpected first patch: %+v", patches[0])
+	}
+	// Trailing blank line before the fence is dropped
+	if lines := patches[0].Hunks[0].Lines; len(lines) != 3 {
+		t.Errorf("expected 3 hunk lines, got %q", lines)
+	}
+
+	if patches[1].Path != "new.sql" || !patches[1].New {
+		t.Errorf("expected new file patch, got %+v", patches[1])
+	}
+
+	// A removed "-- " line is not a file header
+	if patches[2].Path != "old.sql" || len(patches[2].Hunks[0].Lines) != 2 {
+		t.Errorf("unexpected third patch: %+v", patches[2])
+	}
+}
+
+func TestApplyPatches_FuzzyOffset(t *testing.T) {
+	base := map[string]string{
+		"a.go": "package a\n\nimport \"fmt\"\n\nfunc A() {\n\tfmt.Println(1)\n}\n\nfunc B() {\n\tfmt.Println(2)\n}\n",
+	}
+
+	// Both hunks claim wrong line numbers
+	patches := ParsePatches(`--- a/a.go
++++ b/a.go
+@@ -1,3 +1,4 @@
+ func A() {
++	// A prints one
+ 	fmt.Println(1)
+ }
+@@ -20,3 +21,3 @@
+ func B() {
+-	fmt.Println(2)
++	fmt.Println(3)
+ }
+`)
+
+	files, failures := ApplyPatches(testSandbox(t, t.TempDir()), patches, base)
+	if len(failures) != 0 {
+		t.Fatalf("unexpected failures: %v", failures)
+	}
+
+	expected := "package a\n\nimport \"fmt\"\n\nfunc A() {\n\t// A prints one\n\tfmt.Println(1)\n}\n\nfunc B() {\n\tfmt.Println(3)\n}\n"
+	if files["a.go"] != expected {
+		t.Errorf("unexpected content:\n%q\nexpected:\n%q", files["a.go"], expected)
+	}
+}
+
+func TestApplyPatches_WhitespaceTolerant(t *testing.T) {
+	base := map[string]string{
+		"a.go": "func A() {\n\treturn\n}\n",
+	}
+
+	// Context lines lost their tabs
+	patches := ParsePatches("--- a/a.go\n+++ b/a.go\n@@ -1,3 +1,4 @@\n func A() {\n+\tdo()\n return\n }\n")
+
+	files, failures := ApplyPatches(testSandbox(t, t.TempDir()), patches, base)
+	if len(failures) != 0 {
+		t.Fatalf("unexpected failures: %v", failures)
+	}
+	if files["a.go"] != "func A() {\n\tdo()\n\treturn\n}\n" {
+		t.Errorf("unexpected content:\n%q", files["a.go"])
+	}
+}
+
+func TestApplyPatches_NewFile(t *testing.T) {
+	patches := ParsePatches("--- /dev/null\n+++ b/dir/new.go\n@@ -0,0 +1 @@\n+package dir\n")
+
+	files, failures := ApplyPatches(testSandbox(t, t.TempDir()), patches, nil)
+	if len(failures) != 0 {
+		t.Fatalf("unexpected failures: %v", failures)
+	}
+	if files["dir/new.go"] != "package dir\n" {
+		t.Errorf("unexpected content: %q", files["dir/new.go"])
+	}
+}
+
+func TestApplyPatches_NewFileExists(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	patches := ParsePatches("--- /dev/null\n+++ b/main.go\n@@ -0,0 +1 @@\n+package main\n")
+	files, failures := ApplyPatches(testSandbox(t, root), patches, nil)
+	if len(failures) != 1 || failures[0].Reason != "file already exists" {
+		t.Fatalf("expected the new-file patch to fail, got %v", failures)
+	}
+	if _, ok := files["main.go"]; ok {
+		t.Errorf("existing file should not be replaced: %q", files["main.go"])
+	}
+}
+
+func TestApplyPatches_PureInsertion(t *testing.T) {
+	base := map[string]string{"n.txt": "1\n2\n3\n4\n5\n6\n7\n"}
+
+	tests := []struct {
+		patch    string
+		expected string
+	}{
+		// An old count of 0 inserts after the stated line
+		{"@@ -5,0 +6,1 @@\n+NEW\n", "1\n2\n3\n4\n5\nNEW\n6\n7\n"},
+		{"@@ -0,0 +1,2 @@\n+A\n+B\n", "A\nB\n1\n2\n3\n4\n5\n6\n7\n"},
+		{"@@ -7,0 +8 @@\n+END\n", "1\n2\n3\n4\n5\n6\n7\nEND\n"},
+		// Later hunks shift by the inserted lines
+		{"@@ -2,0 +3 @@\n+X\n@@ -4,1 +5,1 @@\n-4\n+four\n", "1\n2\nX\n3\nfour\n5\n6\n7\n"},
+	}
+
+	for _, tt := range tests {
+		files, failures := ApplyPatches(testSandbox(t, t.TempDir()), ParsePatches("--- a/n.txt\n+++ b/n.txt\n"+tt.patch), base)
+		if len(failures) != 0 {
+			t.Errorf("%q: unexpected failures: %v", tt.patch, failures)
+			continue
+		}
+		if files["n.txt"] != tt.expected {
+			t.Errorf("%q: got %q, want %q", tt.patch, files["n.txt"], tt.expected)
+		}
+	}
+}
+
+func TestApplyPatches_Failures(t *testing.T) {
+	base := map[string]string{
+		"a.go": "x := 1\ny := 2\n",
+	}
+
+	patches := ParsePatches(`--- a/a.go
++++ b/a.go
+@@ -1,1 +1,1 @@
+-z := 3
++z := 4
+@@ -2,1 +2,1 @@
+-y := 2
++y := 3
+`)
+
+	files, failures := ApplyPatches(testSandbox(t, t.TempDir()), patches, base)
+	if len(failures) != 1 {
+		t.Fatalf("expected 1 failure, got %v", failures)
+	}
+
+	// Failed hunks come back as edit blocks for the retry prompt
+	f := failures[0]
+	if f.Block.Path != "a.go" || f.Block.Search != "z := 3\n" || f.Block.Replace != "z := 4\n" {
+		t.Errorf("unexpected failure block: %+v", f.Block)
+	}
+	if !strings.Contains(f.Reason, "does not match") {
+		t.Errorf("unexpected reason: %q", f.Reason)
+	}
+
+	// Other hunks still apply
+	if files["a.go"] != "x := 1\ny := 3\n" {
+		t.Errorf("unexpected content:\n%s", files["a.go"])
+	}
+}
+
+func TestApplyPatches_RejectedPath(t *testing.T) {
+	patches := ParsePatches("--- a/../outside.go\n+++ b/../outside.go\n@@ -1 +1 @@\n-a\n+b\n")
+
+	files, failures := ApplyPatches(testSandbox(t, t.TempDir()), patches, nil)
+	if len(failures) != 1 || !strings.HasPrefix(failures[0].Reason, "rejected: outside") || len(files) != 0 {
+		t.Errorf("expected a rejected hunk, got %v, %v", failures, files)
+	}
+}
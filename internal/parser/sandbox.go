@@ -0,0 +1,221 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultProtected are paths the coder may never write, at any depth
+var DefaultProtected = []string{".git", ".github", "go.sum"}
+
+// Sandbox confines reads and writes of coder paths to a repository root
+type Sandbox struct {
+	root      string // Absolute, symlinks resolved
+	absRoot   string // Absolute as given, to place absolute paths
+	protected []protectedPath
+}
+
+// protectedPath matches a run of path components anywhere in a path, like
+// sub/.git/config for ".git", or only at the root if written with a leading "/"
+type protectedPath struct {
+	entry    string
+	parts    []string
+	anchored bool
+}
+
+func (p protectedPath) matches(parts []string) bool {
+	last := len(parts) - len(p.parts)
+	if p.anchored {
+		last = min(last, 0)
+	}
+	for i := 0; i <= last; i++ {
+		if slices.EqualFunc(parts[i:i+len(p.parts)], p.parts, strings.EqualFold) {
+			return true
+		}
+	}
+	return false
+}
+
+func NewSandbox(root string, protected []string) (*Sandbox, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root %s: %w", root, err)
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root %s: %w", root, err)
+	}
+
+	s := &Sandbox{root: resolved, absRoot: abs}
+	for _, entry := range protected {
+		entry = filepath.ToSlash(strings.TrimSpace(entry))
+		p := strings.Trim(path.Clean(entry), "/")
+		if p != "" && p != "." {
+			s.protected = append(s.protected, protectedPath{
+				entry:    entry,
+				parts:    strings.Split(p, "/"),
+				anchored: strings.HasPrefix(entry, "/"),
+			})
+		}
+	}
+	return s, nil
+}
+
+// Rejection is a path the sandbox refused to write
+type Rejection struct {
+	Path   string
+	Reason string
+}
+
+func (r Rejection) Error() string {
+	return fmt.Sprintf("%s: %s", r.Path, r.Reason)
+}
+
+// RejectedError is returned by WriteFiles when any path is rejected; nothing is written
+type RejectedError struct {
+	Rejections []Rejection
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%d path(s) rejected: %v", len(e.Rejections), e.Rejections[0])
+}
+
+// Resolve returns the absolute path of a file inside the root
+func (s *Sandbox) Resolve(path string) (string, error) {
+	rel, err := s.resolve(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, rel), nil
+}
+
+// Rel returns the path of a file relative to the root, in slash form. It is the
+// key a file is known by, so ./a.go and an absolute path to a.go are one file.
+func (s *Sandbox) Rel(path string) (string, error) {
+	rel, err := s.resolve(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// key is Rel, or path itself if the sandbox rejects it
+func (s *Sandbox) key(path string) string {
+	if rel, err := s.Rel(path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func (s *Sandbox) resolve(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", errors.New("empty path")
+	}
+
+	rel := filepath.Clean(path)
+	if filepath.IsAbs(rel) {
+		// Absolute paths are fine under the root, given through a symlink or not
+		if r, err := filepath.Rel(s.absRoot, rel); err == nil && filepath.IsLocal(r) {
+			rel = r
+		} else if r, err := filepath.Rel(s.root, rel); err == nil {
+			rel = r
+		}
+	}
+	if rel == "." {
+		return "", errors.New("is the repository root")
+	}
+	if !filepath.IsLocal(rel) {
+		return "", errors.New("outside the repository root")
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, p := range s.protected {
+		if p.matches(parts) {
+			return "", fmt.Errorf("protected path %s", p.entry)
+		}
+	}
+
+	// Every existing component must be a real directory or file, not a link out
+	current := s.root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("symlink %s", filepath.ToSlash(strings.TrimPrefix(current, s.root+string(filepath.Separator))))
+		}
+	}
+
+	return rel, nil
+}
+
+// ReadFile reads a file inside the root, with exists false if it is missing.
+// A path the sandbox rejects is never read; it is returned as a Rejection error.
+func (s *Sandbox) ReadFile(path string) (content string, exists bool, err error) {
+	target, err := s.Resolve(path)
+	if err != nil {
+		return "", false, Rejection{Path: path, Reason: err.Error()}
+	}
+
+	data, err := os.ReadFile(target)
+	switch {
+	case err == nil:
+		return string(data), true, nil
+	case errors.Is(err, fs.ErrNotExist):
+		return "", false, nil
+	}
+	return "", false, fmt.Errorf("failed to read %s: %w", path, err)
+}
+
+// mode returns the permissions of a file inside the root
+func (s *Sandbox) mode(path string) (fs.FileMode, error) {
+	target, err := s.Resolve(path)
+	if err != nil {
+		return 0, Rejection{Path: path, Reason: err.Error()}
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	return info.Mode().Perm(), nil
+}
+
+// NormalizeFiles re-keys files by Rel. Rejected paths keep their key, to be
+// refused when written. Two paths to one file with different content are an error.
+func (s *Sandbox) NormalizeFiles(files map[string]string) (map[string]string, error) {
+	normalized := make(map[string]string, len(files))
+	from := make(map[string]string)
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		key := s.key(path)
+		if other, ok := from[key]; ok && normalized[key] != files[path] {
+			return nil, fmt.Errorf("%s and %s are the same file with different content", other, path)
+		}
+		normalized[key], from[key] = files[path], path
+	}
+	return normalized, nil
+}
+
+// Check returns the rejected paths, sorted
+func (s *Sandbox) Check(paths []string) []Rejection {
+	var rejections []Rejection
+	for _, path := range paths {
+		if _, err := s.Resolve(path); err != nil {
+			rejections = append(rejections, Rejection{Path: path, Reason: err.Error()})
+		}
+	}
+	sort.Slice(rejections, func(i, j int) bool { return rejections[i].Path < rejections[j].Path })
+	return rejections
+}
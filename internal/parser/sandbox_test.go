@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testSandbox(t *testing.T, root string) *Sandbox {
+	t.Helper()
+	s, err := NewSandbox(root, DefaultProtected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSandboxResolve(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	s := testSandbox(t, dir)
+
+	tests := []struct {
+		path   string
+		reason string // Empty if allowed
+	}{
+		{"main.go", ""},
+		{"internal/new/file.go", ""},
+		{"./a/../b.go", ""},
+		{filepath.Join(dir, "abs.go"), ""},
+		{"../escape.go", "outside"},
+		{"a/../../escape.go", "outside"},
+		{"/etc/passwd", "outside"},
+		{".", "repository root"},
+		{"", "empty"},
+		{".git/hooks/pre-commit", "protected"},
+		{".GitHub/workflows/ci.yml", "protected"},
+		{"go.sum", "protected"},
+		{"sub/go.sum", "protected"},
+		{"sub/.git/config", "protected"},
+		{"tools/x/.GITHUB/a.yml", "protected"},
+		{"go.sum.bak", ""},
+		{"sub/.gitignore", ""},
+		{".gitignore", ""},
+		{"link/x.go", "symlink"},
+	}
+
+	for _, tt := range tests {
+		_, err := s.Resolve(tt.path)
+		switch {
+		case tt.reason == "" && err != nil:
+			t.Errorf("Resolve(%q) = %v, want allowed", tt.path, err)
+		case tt.reason != "" && (err == nil || !strings.Contains(err.Error(), tt.reason)):
+			t.Errorf("Resolve(%q) = %v, want %q", tt.path, err, tt.reason)
+		}
+	}
+}
+
+func TestSandboxResolve_Protected(t *testing.T) {
+	s, err := NewSandbox(t.TempDir(), []string{"/docs/private", "secrets/keys", " "})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path      string
+		protected bool
+	}{
+		{"docs/private/a.md", true},
+		{"docs/private", true},
+		{"sub/docs/private/a.md", false}, // Anchored at the root
+		{"docs/public/a.md", false},
+		{"secrets/keys/id", true},
+		{"svc/secrets/keys/id", true},
+		{"svc/secrets/other", false},
+	}
+
+	for _, tt := range tests {
+		_, err := s.Resolve(tt.path)
+		if got := err != nil && strings.Contains(err.Error(), "protected"); got != tt.protected {
+			t.Errorf("Resolve(%q) = %v, want protected %v", tt.path, err, tt.protected)
+		}
+	}
+}
+
+func TestSandboxNormalizeFiles(t *testing.T) {
+	dir := t.TempDir()
+	s := testSandbox(t, dir)
+
+	files, err := s.NormalizeFiles(map[string]string{
+		"./a.go":                     "package a\n",
+		filepath.Join(dir, "b/c.go"): "package b\n",
+		"b/../b/c.go":                "package b\n",
+		"../x.go":                    "escape",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a.go": "package a\n", "b/c.go": "package b\n", "../x.go": "escape"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("NormalizeFiles() = %v, want %v", files, want)
+	}
+
+	// One file with two contents is ambiguous
+	if _, err := s.NormalizeFiles(map[string]string{"a.go": "one", "./a.go": "two"}); err == nil {
+		t.Error("two contents for one file should fail")
+	}
+}
+
+func TestWriteFiles_Rejected(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := WriteFiles(context.Background(), testSandbox(t, dir), map[string]string{
+		"ok.go":   "package ok",
+		"../x.go": "escape",
+	}, []string{".github/workflows/a"}, nil)
+
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || len(rejected.Rejections) != 2 {
+		t.Fatalf("WriteFiles() = %v, want 2 rejections", err)
+	}
+	if rejected.Rejections[0].Path != "../x.go" || rejected.Rejections[1].Path != ".github/workflows/a" {
+		t.Errorf("unexpected rejections: %v", rejected.Rejections)
+	}
+
+	// Nothing is written when any path is rejected
+	if _, err := os.Stat(filepath.Join(dir, "ok.go")); !os.IsNotExist(err) {
+		t.Error("no file should be written")
+	}
+}
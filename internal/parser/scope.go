@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// What to do with files outside the task scope
+const (
+	ScopeError = "error" // Fail the run, nothing is written
+	ScopeWarn  = "warn"  // Write them and report them
+	ScopeNote  = "note"  // Leave them out and report them for the PR
+)
+
+// Scope is the set of files a task may change. A path ending in "/" allows
+// everything under it.
+type Scope struct {
+	files map[string]bool
+	dirs  []string
+}
+
+func NewScope(paths []string) *Scope {
+	s := &Scope{files: make(map[string]bool)}
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if strings.HasSuffix(p, "/") {
+			s.dirs = append(s.dirs, cleanScopePath(p)+"/")
+		} else {
+			s.files[cleanScopePath(p)] = true
+		}
+	}
+	return s
+}
+
+// Empty reports whether the scope allows nothing, i.e. is unknown
+func (s *Scope) Empty() bool {
+	return len(s.files) == 0 && len(s.dirs) == 0
+}
+
+func (s *Scope) Allows(path string) bool {
+	path = cleanScopePath(path)
+	if s.files[path] {
+		return true
+	}
+	for _, dir := range s.dirs {
+		if strings.HasPrefix(path, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// OutOfScope returns the paths the scope does not allow, sorted
+func (s *Scope) OutOfScope(paths []string) []string {
+	var out []string
+	for _, p := range paths {
+		if !s.Allows(p) {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func cleanScopePath(p string) string {
+	return filepath.ToSlash(filepath.Clean(p))
+}
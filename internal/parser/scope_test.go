@@ -0,0 +1,28 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	s := NewScope([]string{"internal/auth/handler.go", "./cmd/main.go", "docs/", " "})
+
+	got := s.OutOfScope([]string{
+		"internal/auth/handler.go",
+		"cmd/main.go",
+		"./internal/auth/../auth/handler.go",
+		"docs/tasks/01.md",
+		"internal/auth/other.go",
+		"docs.go",
+	})
+
+	expected := []string{"docs.go", "internal/auth/other.go"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("OutOfScope() = %v, want %v", got, expected)
+	}
+
+	if s.Empty() || !NewScope(nil).Empty() {
+		t.Error("only a scope without paths is empty")
+	}
+}
@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	goparser "go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Errors beyond this are summarized, the first ones are enough to repair
+const maxSyntaxErrors = 10
+
+const commandCheckTimeout = 30 * time.Second
+
+// Checker reports syntax errors in the content of a file
+type Checker func(path, content string) error
+
+// SyntaxError is a file that failed its checker
+type SyntaxError struct {
+	Path    string
+	Message string
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validator checks files by extension
+type Validator struct {
+	checkers map[string]Checker
+}
+
+// NewValidator checks .go and .json files
+func NewValidator() *Validator {
+	return &Validator{checkers: map[string]Checker{
+		".go":   checkGo,
+		".json": checkJSON,
+	}}
+}
+
+// Register sets the checker of an extension such as ".py", nil removes it
+func (v *Validator) Register(ext string, c Checker) {
+	if c == nil {
+		delete(v.checkers, ext)
+		return
+	}
+	v.checkers[ext] = c
+}
+
+// Validate returns the files that fail their checker, sorted by path
+func (v *Validator) Validate(files map[string]string) []SyntaxError {
+	var errs []SyntaxError
+	for path, content := range files {
+		check, ok := v.checkers[filepath.Ext(path)]
+		if !ok {
+			continue
+		}
+		if err := check(path, content); err != nil {
+			errs = append(errs, SyntaxError{Path: path, Message: err.Error()})
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs
+}
+
+func checkGo(_, content string) error {
+	// No file name, positions read line:column after the path of the SyntaxError
+	_, err := goparser.ParseFile(token.NewFileSet(), "", content, goparser.AllErrors)
+	var list scanner.ErrorList
+	if errors.As(err, &list) {
+		// One error per line, ErrorList.Error only shows the first
+		var lines []string
+		for i, e := range list {
+			if i == maxSyntaxErrors {
+				lines = append(lines, fmt.Sprintf("(and %d more errors)", len(list)-i))
+				break
+			}
+			lines = append(lines, e.Error())
+		}
+		return errors.New(strings.Join(lines, "\n"))
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := format.Source([]byte(content)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func checkJSON(_, content string) error {
+	var v any
+	if err := json.Unmarshal([]byte(content), &v); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CommandChecker runs a command with the content in a temp file as last argument,
+// e.g. "python3 -m py_compile"; a non-zero exit is a syntax error with its output
+func CommandChecker(command string) Checker {
+	args := strings.Fields(command)
+
+	return func(path, content string) error {
+		tmp, err := os.CreateTemp("", "check-*"+filepath.Ext(path))
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+
+		if _, err := tmp.WriteString(content); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+
+		c, cancel := context.WithTimeout(context.Background(), commandCheckTimeout)
+		defer cancel()
+
+		out, err := exec.CommandContext(c, args[0], append(args[1:], tmp.Name())...).CombinedOutput()
+		if err != nil {
+			msg := strings.TrimSpace(strings.ReplaceAll(string(out), tmp.Name(), path))
+			if msg == "" {
+				msg = err.Error()
+			}
+			return errors.New(msg)
+		}
+		return nil
+	}
+}
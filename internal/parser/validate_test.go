@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestValidator_Builtin(t *testing.T) {
+	files := map[string]string{
+		"ok.go":      "package ok\n\nfunc A() {}\n",
+		"broken.go":  "package broken\n\nfunc A() {\n",
+		"ok.json":    `{"a": [1, 2]}`,
+		"bad.json":   `{"a": }`,
+		"notes.txt":  "func (",
+		"missing.go": "func A() {}\n",
+	}
+
+	errs := NewValidator().Validate(files)
+
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+	for i, want := range []string{"bad.json", "broken.go", "missing.go"} {
+		if errs[i].Path != want {
+			t.Errorf("error %d = %s, want %s", i, errs[i].Path, want)
+		}
+	}
+	// Go errors carry positions
+	if !strings.HasPrefix(errs[1].Message, "3:") {
+		t.Errorf("expected position in %q", errs[1].Message)
+	}
+}
+
+func TestValidator_Register(t *testing.T) {
+	v := NewValidator()
+	v.Register(".go", nil)
+	v.Register(".txt", func(path, content string) error {
+		if strings.Contains(content, "TODO") {
+			return errors.New("found TODO")
+		}
+		return nil
+	})
+
+	errs := v.Validate(map[string]string{
+		"broken.go": "func (",
+		"a.txt":     "TODO",
+	})
+
+	if len(errs) != 1 || errs[0].Path != "a.txt" {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestCommandChecker(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	check := CommandChecker("sh -n")
+
+	if err := check("ok.sh", "echo hi\n"); err != nil {
+		t.Errorf("valid script: %v", err)
+	}
+
+	err := check("bad.sh", "if then\n")
+	if err == nil {
+		t.Fatal("expected syntax error")
+	}
+	// Messages name the file, not the temp copy
+	if strings.Contains(err.Error(), "check-") {
+		t.Errorf("temp path leaked into %q", err)
+	}
+}
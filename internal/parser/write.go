@@ -0,0 +1,282 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Change actions
+const (
+	ActionCreated  = "created"
+	ActionModified = "modified"
+	ActionDeleted  = "deleted"
+)
+
+// Mode of files the writer creates; existing files keep theirs
+const newFileMode fs.FileMode = 0644
+
+// Change is a file the writer changed on disk
+type Change struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+}
+
+// pendingChange is one file of a transaction
+type pendingChange struct {
+	path     string
+	target   string // Resolved inside the sandbox
+	tmp      string // Staged content, empty for a delete
+	existed  bool
+	original []byte // Restored on rollback
+	mode     fs.FileMode
+	action   string
+}
+
+// WriteFiles writes files and deletes paths as one transaction inside the sandbox:
+// every file is staged in a synced temp file next to its target, then all are
+// renamed into place in sorted order, deletes last. If anything fails, files
+// already replaced are restored and nothing is left changed. Files whose content
+// is unchanged are skipped. If any path is rejected, nothing is written. A
+// rename target is created with the mode of the file it was renamed from.
+func WriteFiles(ctx context.Context, sandbox *Sandbox, files map[string]string, deletes []string, renames Renames) ([]Change, error) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	deletes = append([]string(nil), deletes...)
+	sort.Strings(deletes)
+
+	if rejections := sandbox.Check(append(append([]string(nil), paths...), deletes...)); len(rejections) > 0 {
+		return nil, &RejectedError{Rejections: rejections}
+	}
+
+	var pending []*pendingChange
+	var createdDirs []string
+	cleanup := func() {
+		for _, p := range pending {
+			if p.tmp != "" {
+				os.Remove(p.tmp)
+			}
+		}
+		removeEmptyDirs(createdDirs)
+	}
+
+	// === Stage ===
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("writing files canceled: %w", err)
+		}
+
+		p, err := loadPending(sandbox, path)
+		if err == nil && !p.existed {
+			p.mode, err = createMode(sandbox, path, renames)
+		}
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		content := []byte(files[path])
+		if p.existed && bytes.Equal(p.original, content) {
+			continue
+		}
+
+		p.action = ActionModified
+		if !p.existed {
+			p.action = ActionCreated
+		}
+
+		dirs, err := mkdirAll(filepath.Dir(p.target))
+		createdDirs = append(createdDirs, dirs...)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+
+		pending = append(pending, p)
+		if p.tmp, err = stageFile(p.target, content, p.mode); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+	}
+
+	for _, path := range deletes {
+		p, err := loadPending(sandbox, path)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		if !p.existed {
+			continue
+		}
+		p.action = ActionDeleted
+		pending = append(pending, p)
+	}
+
+	if err := ctx.Err(); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("writing files canceled: %w", err)
+	}
+
+	// === Commit === (not interrupted by ctx, renames are quick)
+	for i, p := range pending {
+		var err error
+		if p.action == ActionDeleted {
+			err = os.Remove(p.target)
+		} else {
+			err = os.Rename(p.tmp, p.target)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to %s %s: %w", actionVerb(p.action), p.path, err)
+			rollback(pending[:i])
+			cleanup()
+			return nil, err
+		}
+		p.tmp = ""
+		syncDir(filepath.Dir(p.target))
+	}
+
+	changes := make([]Change, 0, len(pending))
+	for _, p := range pending {
+		slog.Info("Changed file", "path", p.path, "action", p.action)
+		changes = append(changes, Change{Path: p.path, Action: p.action})
+	}
+	return changes, nil
+}
+
+// createMode is the mode of a new file: that of the file it was renamed from,
+// if any, or newFileMode
+func createMode(sandbox *Sandbox, path string, renames Renames) (fs.FileMode, error) {
+	origin, renamed := renames[path]
+	if !renamed {
+		return newFileMode, nil
+	}
+	mode, err := sandbox.mode(origin)
+	if errors.Is(err, fs.ErrNotExist) {
+		return newFileMode, nil
+	}
+	return mode, err
+}
+
+// loadPending resolves a path and reads what is there now
+func loadPending(sandbox *Sandbox, path string) (*pendingChange, error) {
+	target, err := sandbox.Resolve(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	p := &pendingChange{path: path, target: target, mode: newFileMode}
+	info, err := os.Lstat(target)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return p, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	case !info.Mode().IsRegular():
+		return nil, fmt.Errorf("failed to read %s: not a regular file", path)
+	}
+
+	if p.original, err = os.ReadFile(target); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	p.existed, p.mode = true, info.Mode().Perm()
+	return p, nil
+}
+
+// stageFile writes synced content to a temp file in the target's directory
+func stageFile(target string, data []byte, perm fs.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// rollback restores committed changes in reverse order
+func rollback(committed []*pendingChange) {
+	for i := len(committed) - 1; i >= 0; i-- {
+		p := committed[i]
+
+		var err error
+		if p.existed {
+			var tmp string
+			if tmp, err = stageFile(p.target, p.original, p.mode); err == nil {
+				if err = os.Rename(tmp, p.target); err != nil {
+					os.Remove(tmp)
+				}
+			}
+		} else {
+			err = os.Remove(p.target)
+		}
+
+		if err != nil {
+			slog.Error("Failed to restore file", "path", p.path, "error", err)
+		} else {
+			slog.Warn("Restored file", "path", p.path)
+		}
+	}
+}
+
+// mkdirAll creates dir and returns the directories it created, outermost first
+func mkdirAll(dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil || filepath.Dir(d) == d {
+			break
+		}
+		missing = append([]string{d}, missing...)
+	}
+
+	var created []string
+	for _, d := range missing {
+		if err := os.Mkdir(d, 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+			return created, err
+		}
+		created = append(created, d)
+	}
+	return created, nil
+}
+
+// removeEmptyDirs removes directories created by a failed transaction, innermost first
+func removeEmptyDirs(dirs []string) {
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i]) // Fails, as intended, if not empty
+	}
+}
+
+// syncDir makes a rename durable, best effort
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+func actionVerb(action string) string {
+	if action == ActionDeleted {
+		return "delete"
+	}
+	return "write"
+}
@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"same.txt": "same", "mod.txt": "old", "gone.txt": "gone"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]string{
+		filepath.Join(dir, "same.txt"):     "same",
+		filepath.Join(dir, "mod.txt"):      "new",
+		filepath.Join(dir, "sub", "a.txt"): "a",
+	}
+	deletes := []string{filepath.Join(dir, "gone.txt"), filepath.Join(dir, "already-gone.txt")}
+
+	changes, err := WriteFiles(context.Background(), testSandbox(t, dir), files, deletes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sorted writes, then deletes; unchanged files are skipped
+	expected := []Change{
+		{Path: filepath.Join(dir, "mod.txt"), Action: ActionModified},
+		{Path: filepath.Join(dir, "sub", "a.txt"), Action: ActionCreated},
+		{Path: filepath.Join(dir, "gone.txt"), Action: ActionDeleted},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("WriteFiles() = %v, want %v", changes, expected)
+	}
+
+	for path, want := range files {
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v", path, got, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gone.txt")); !os.IsNotExist(err) {
+		t.Error("gone.txt should be deleted")
+	}
+
+	// No temp files are left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("unexpected files in %s: %v", dir, entries)
+	}
+}
+
+func TestWriteFiles_KeepsMode(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "run.sh")
+	if err := os.WriteFile(script, []byte("echo old\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := WriteFiles(context.Background(), testSandbox(t, dir), map[string]string{script: "echo new\n"}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(script)
+	if err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("mode = %v, %v, want executable bit kept", info.Mode(), err)
+	}
+}
+
+func TestWriteFiles_RenameKeepsConventionsAndMode(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\r\necho old\r\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sandbox := testSandbox(t, dir)
+
+	files, deletes, renames, err := ApplyFileOps(sandbox, []FileOp{{Path: "run.sh", NewPath: "bin/run.sh"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["bin/run.sh"] = "#!/bin/sh\necho new\n"
+
+	if err := KeepConventions(sandbox, files, renames); err != nil {
+		t.Fatal(err)
+	}
+	if files["bin/run.sh"] != "#!/bin/sh\r\necho new\r\n" {
+		t.Errorf("rename target should keep CRLF: %q", files["bin/run.sh"])
+	}
+
+	patch, err := DiffFiles(sandbox, files, renames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(patch, "new file mode 100755\n") {
+		t.Errorf("rename target should keep the executable mode:\n%s", patch)
+	}
+
+	if _, err := WriteFiles(context.Background(), sandbox, files, deletes, renames); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "bin/run.sh"))
+	if err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("mode = %v, %v, want 0755", info, err)
+	}
+}
+
+func TestWriteFiles_Rollback(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(first, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A non-empty directory cannot be replaced by a file, so the second rename fails
+	blocker := filepath.Join(dir, "b")
+	if err := os.MkdirAll(filepath.Join(blocker, "child"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := WriteFiles(context.Background(), testSandbox(t, dir), map[string]string{
+		first:                              "changed",
+		filepath.Join(dir, "new", "c.txt"): "created",
+		blocker:                            "file",
+	}, nil, nil)
+	if err == nil {
+		t.Fatal("expected write failure")
+	}
+
+	if got, _ := os.ReadFile(first); string(got) != "original" {
+		t.Errorf("a.txt = %q, want restored original", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new")); !os.IsNotExist(err) {
+		t.Error("created directory should be removed")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("unexpected files in %s: %v", dir, entries)
+	}
+}
+
+func TestWriteFiles_Canceled(t *testing.T) {
+	dir := t.TempDir()
+	c, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	changes, err := WriteFiles(c, testSandbox(t, dir), map[string]string{filepath.Join(dir, "a.txt"): "a"}, nil, nil)
+	if err == nil || len(changes) != 0 {
+		t.Fatalf("WriteFiles() = %v, %v, want cancellation", changes, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "a.txt")); !os.IsNotExist(err) {
+		t.Error("no file should be written after cancellation")
+	}
+}
@@ -31,7 +31,11 @@ func GetLanguagePrompt(lang Language) *LanguagePrompt {
 		}
 	}
 
-	// Fall back to built-in prompts
+	return BuiltinLanguagePrompt(lang)
+}
+
+// BuiltinLanguagePrompt ignores custom docs in docs/tasks/languages/
+func BuiltinLanguagePrompt(lang Language) *LanguagePrompt {
 	switch lang {
 	case LangGo:
 		return getGoPrompt()
@@ -44,8 +48,9 @@ func GetLanguagePrompt(lang Language) *LanguagePrompt {
 	}
 }
 
-func loadCustomLanguageDoc(lang Language) string {
-	path := filepath.Join("docs", "tasks", "languages", string(lang)+".md")
+// Loads custom standards for a language, empty if none
+var languageDocLoader = func(lang string) string {
+	path := filepath.Join("docs", "tasks", "languages", lang+".md")
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return ""
@@ -53,10 +58,19 @@ func loadCustomLanguageDoc(lang Language) string {
 	return string(data)
 }
 
+// SetLanguageDocLoader overrides where custom language docs are read from
+func SetLanguageDocLoader(loader func(lang string) string) {
+	languageDocLoader = loader
+}
+
+func loadCustomLanguageDoc(lang Language) string {
+	return languageDocLoader(string(lang))
+}
+
 func DetectLanguage(envVar string, taskContent string, targetFiles []string, changedFiles []string) Language {
 	// Priority 1: Environment variable
 	if envVar != "" {
-		if lang := parseLanguage(envVar); lang != LangUnknown {
+		if lang := ParseLanguage(envVar); lang != LangUnknown {
 			return lang
 		}
 	}
@@ -80,7 +94,8 @@ func DetectLanguage(envVar string, taskContent string, targetFiles []string, cha
 	return LangGo
 }
 
-func parseLanguage(s string) Language {
+// ParseLanguage maps a LANGUAGE value or alias to a built-in language
+func ParseLanguage(s string) Language {
 	switch strings.ToLower(strings.TrimSpace(s)) {
 	case "go", "golang":
 		return LangGo
@@ -100,7 +115,7 @@ func parseLanguageFromTask(content string) Language {
 		if strings.HasPrefix(strings.ToUpper(trimmed), "LANGUAGE:") {
 			value := strings.TrimSpace(strings.TrimPrefix(trimmed, "LANGUAGE:"))
 			value = strings.TrimSpace(strings.TrimPrefix(value, "language:"))
-			return parseLanguage(value)
+			return ParseLanguage(value)
 		}
 	}
 	return LangUnknown
@@ -163,3 +178,28 @@ func (p *LanguagePrompt) FormatForPrompt() string {
 
 	return b.String()
 }
+
+// Markdown renders the prompt as a language doc for docs/tasks/languages/
+func (p *LanguagePrompt) Markdown() string {
+	if p.Language == LangUnknown {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(p.Standards)
+	b.WriteString("\n")
+
+	if p.Patterns != "" {
+		b.WriteString("\nRECOMMENDED PATTERNS:\n")
+		b.WriteString(p.Patterns)
+		b.WriteString("\n")
+	}
+
+	if p.AntiPatterns != "" {
+		b.WriteString("\nAVOID:\n")
+		b.WriteString(p.AntiPatterns)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -28,9 +28,9 @@ func TestParseLanguage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			result := parseLanguage(tt.input)
+			result := ParseLanguage(tt.input)
 			if result != tt.expected {
-				t.Errorf("parseLanguage(%q) = %v, expected %v", tt.input, result, tt.expected)
+				t.Errorf("ParseLanguage(%q) = %v, expected %v", tt.input, result, tt.expected)
 			}
 		})
 	}
@@ -272,6 +272,24 @@ func TestFormatForPrompt(t *testing.T) {
 	})
 }
 
+func TestMarkdown(t *testing.T) {
+	orig := languageDocLoader
+	defer SetLanguageDocLoader(orig)
+	SetLanguageDocLoader(func(string) string { return "custom" })
+
+	md := BuiltinLanguagePrompt(LangGo).Markdown()
+	if contains(md, "custom") || !contains(md, "Go Coding Standards") {
+		t.Error("built-in prompt should ignore custom docs")
+	}
+	if !contains(md, "RECOMMENDED PATTERNS:") || !contains(md, "AVOID:") {
+		t.Error("should contain patterns and anti-patterns")
+	}
+
+	if BuiltinLanguagePrompt(LangUnknown).Markdown() != "" {
+		t.Error("unknown language should return empty string")
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsImpl(s, substr))
 }
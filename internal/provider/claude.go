@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+)
+
+var claudeModels = []string{
+	"claude-sonnet-4-5", // primary
+	"claude-opus-4-1",   // fallback
+}
+
+const (
+	claudeBaseURL    = "https://api.anthropic.com"
+	claudeAPIVersion = "2023-06-01"
+	claudeMaxTokens  = 32000
+)
+
+type Claude struct {
+	usageTracker
+	apiKey     string
+	baseURL    string
+	models     []string
+	maxRetries int
+}
+
+type claudeRequest struct {
+	Model     string          `json:"model"`
+	MaxTokens int             `json:"max_tokens"`
+	Messages  []claudeMessage `json:"messages"`
+}
+
+type claudeMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type claudeResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func NewClaude(creds *config.Credentials, maxRetries int) *Claude {
+	baseURL := creds.BaseURL
+	if baseURL == "" {
+		baseURL = claudeBaseURL
+	}
+
+	return &Claude{
+		apiKey:     creds.APIKey,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		models:     modelsFor(creds, claudeModels),
+		maxRetries: maxRetries,
+	}
+}
+
+func (c *Claude) Name() string {
+	return "claude"
+}
+
+func (c *Claude) Generate(ctx context.Context, prompt string) (string, error) {
+	return generateWithRetry(ctx, c.models, c.maxRetries, func(ctx context.Context, model string) (string, error) {
+		req := claudeRequest{
+			Model:     model,
+			MaxTokens: claudeMaxTokens,
+			Messages:  []claudeMessage{{Role: "user", Content: prompt}},
+		}
+		headers := map[string]string{
+			"x-api-key":         c.apiKey,
+			"anthropic-version": claudeAPIVersion,
+		}
+
+		var resp claudeResponse
+		if err := postJSON(ctx, c.baseURL+"/v1/messages", headers, req, &resp); err != nil {
+			return "", err
+		}
+
+		c.record(resp.Usage.InputTokens, resp.Usage.OutputTokens)
+
+		var result strings.Builder
+		for _, block := range resp.Content {
+			if block.Type == "text" {
+				result.WriteString(block.Text)
+			}
+		}
+		if result.Len() == 0 {
+			return "", fmt.Errorf("empty response from %s", model)
+		}
+
+		return result.String(), nil
+	})
+}
@@ -3,10 +3,10 @@ package provider
 import (
 	"context"
 	"fmt"
-	"log"
+	"net/url"
 	"strings"
-	"time"
 
+	"github.com/esifea/ai-driven-automation/internal/config"
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
 )
@@ -17,17 +17,41 @@ var models = []string{
 }
 
 type Gemini struct {
+	usageTracker
 	client     *genai.Client
+	models     []string
 	maxRetries int
 }
 
-func NewGemini(apiKey string, maxRetries int) (*Gemini, error) {
+func NewGemini(creds *config.Credentials, maxRetries int) (*Gemini, error) {
 	ctx := context.Background()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+
+	opts := []option.ClientOption{option.WithAPIKey(creds.APIKey)}
+	if creds.BaseURL != "" {
+		endpoint, err := geminiEndpoint(creds.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, option.WithEndpoint(endpoint))
+	}
+
+	client, err := genai.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gemini client: %w", err)
 	}
-	return &Gemini{client: client, maxRetries: maxRetries}, nil
+	return &Gemini{client: client, models: modelsFor(creds, models), maxRetries: maxRetries}, nil
+}
+
+// geminiEndpoint turns a base URL into the endpoint of the REST client, which
+// appends the API version (/v1beta/...) itself
+func geminiEndpoint(baseURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid gemini base URL %q: expected http(s)://host[:port][/path]", baseURL)
+	}
+
+	path := strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/v1beta")
+	return u.Scheme + "://" + u.Host + path, nil
 }
 
 func (g *Gemini) Name() string {
@@ -35,35 +59,22 @@ func (g *Gemini) Name() string {
 }
 
 func (g *Gemini) Generate(ctx context.Context, prompt string) (string, error) {
-	var lastErr error
-
-	for attempt := 0; attempt < g.maxRetries; attempt++ {
-		modelName := models[attempt%len(models)]
-		log.Printf("Generating with %s (Attempt %d/%d)", modelName, attempt+1, g.maxRetries)
-
+	return generateWithRetry(ctx, g.models, g.maxRetries, func(ctx context.Context, modelName string) (string, error) {
 		model := g.client.GenerativeModel(modelName)
 		resp, err := model.GenerateContent(ctx, genai.Text(prompt))
-
-		if err == nil && resp != nil {
-			return extractText(resp), nil
+		if err != nil {
+			return "", err
 		}
-
-		lastErr = err
-		errMsg := err.Error()
-		log.Printf("Failed with %s: %s", modelName, errMsg)
-
-		sleepTime := 15 * time.Duration(attempt+1) * time.Second
-		if strings.Contains(errMsg, "503") || strings.Contains(strings.ToLower(errMsg), "overloaded") {
-			log.Println("Server overloaded. Waiting 30s...")
-			sleepTime = 30 * time.Second
+		if resp == nil {
+			return "", fmt.Errorf("empty response from %s", modelName)
 		}
 
-		if attempt < g.maxRetries-1 {
-			time.Sleep(sleepTime)
+		if resp.UsageMetadata != nil {
+			g.record(int(resp.UsageMetadata.PromptTokenCount), int(resp.UsageMetadata.CandidatesTokenCount))
 		}
-	}
 
-	return "", fmt.Errorf("all retries failed: %w", lastErr)
+		return extractText(resp), nil
+	})
 }
 
 func extractText(resp *genai.GenerateContentResponse) string {
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+)
+
+var openAIModels = []string{
+	"gpt-5",   // primary
+	"gpt-4.1", // fallback
+}
+
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAI also serves OpenAI-compatible endpoints via a custom base URL
+type OpenAI struct {
+	usageTracker
+	apiKey     string
+	baseURL    string
+	models     []string
+	maxRetries int
+}
+
+type openAIRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func NewOpenAI(creds *config.Credentials, maxRetries int) *OpenAI {
+	baseURL := creds.BaseURL
+	if baseURL == "" {
+		baseURL = openAIBaseURL
+	}
+
+	return &OpenAI{
+		apiKey:     creds.APIKey,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		models:     modelsFor(creds, openAIModels),
+		maxRetries: maxRetries,
+	}
+}
+
+func (o *OpenAI) Name() string {
+	return "openai"
+}
+
+func (o *OpenAI) Generate(ctx context.Context, prompt string) (string, error) {
+	return generateWithRetry(ctx, o.models, o.maxRetries, func(ctx context.Context, model string) (string, error) {
+		req := openAIRequest{
+			Model:    model,
+			Messages: []openAIMessage{{Role: "user", Content: prompt}},
+		}
+
+		headers := map[string]string{}
+		if o.apiKey != "" {
+			headers["Authorization"] = "Bearer " + o.apiKey
+		}
+
+		var resp openAIResponse
+		if err := postJSON(ctx, o.baseURL+"/chat/completions", headers, req, &resp); err != nil {
+			return "", err
+		}
+
+		o.record(resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+
+		if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+			return "", fmt.Errorf("empty response from %s", model)
+		}
+
+		return resp.Choices[0].Message.Content, nil
+	})
+}
@@ -1,6 +1,11 @@
 package provider
 
-import "context"
+import (
+	"context"
+	"fmt"
+
+	"github.com/esifea/ai-driven-automation/internal/config"
+)
 
 type Provider interface {
 	Generate(ctx context.Context, prompt string) (string, error)
@@ -8,13 +13,23 @@ type Provider interface {
 	Name() string
 }
 
-func NewProvider(name, apiKey string, maxRetries int) (Provider, error) {
-	switch name {
+func NewProvider(creds *config.Credentials, maxRetries int) (Provider, error) {
+	switch creds.Provider {
 	case "gemini":
-		return NewGemini(apiKey, maxRetries)
-	// case "claude":
-	//     return NewClaude(apiKey, maxRetries)
+		return NewGemini(creds, maxRetries)
+	case "claude":
+		return NewClaude(creds, maxRetries), nil
+	case "openai":
+		return NewOpenAI(creds, maxRetries), nil
 	default:
-		return NewGemini(apiKey, maxRetries)
+		return nil, fmt.Errorf("unsupported provider %q", creds.Provider)
+	}
+}
+
+// modelsFor returns the configured model, or the provider's defaults
+func modelsFor(creds *config.Credentials, defaults []string) []string {
+	if creds.Model != "" {
+		return []string{creds.Model}
 	}
+	return defaults
 }
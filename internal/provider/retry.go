@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+type generateFunc func(ctx context.Context, model string) (string, error)
+
+// generateWithRetry rotates through models until one succeeds or retries run out
+func generateWithRetry(ctx context.Context, models []string, maxRetries int, generate generateFunc) (string, error) {
+	if maxRetries < 1 {
+		maxRetries = 1
+	}
+
+	var lastErr error
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return "", fmt.Errorf("generation canceled: %w", err)
+		}
+
+		modelName := models[attempt%len(models)]
+		slog.Info("Generating", "model", modelName, "attempt", attempt+1, "max_attempts", maxRetries)
+
+		text, err := generate(ctx, modelName)
+		if err == nil {
+			return text, nil
+		}
+
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("generation canceled: %w", ctx.Err())
+		}
+
+		lastErr = err
+		errMsg := err.Error()
+		slog.Warn("Generation failed", "model", modelName, "error", errMsg)
+
+		sleepTime := 15 * time.Duration(attempt+1) * time.Second
+		if isOverloaded(errMsg) {
+			slog.Warn("Server overloaded, waiting 30s")
+			sleepTime = 30 * time.Second
+		}
+
+		if attempt < maxRetries-1 {
+			select {
+			case <-ctx.Done():
+				return "", fmt.Errorf("generation canceled: %w", ctx.Err())
+			case <-time.After(sleepTime):
+			}
+		}
+	}
+
+	return "", fmt.Errorf("all retries failed: %w", lastErr)
+}
+
+func isOverloaded(errMsg string) bool {
+	lower := strings.ToLower(errMsg)
+	return strings.Contains(errMsg, "503") || strings.Contains(errMsg, "529") || strings.Contains(lower, "overloaded")
+}
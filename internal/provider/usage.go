@@ -0,0 +1,42 @@
+package provider
+
+import "sync"
+
+type Usage struct {
+	Calls        int `json:"calls"`
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		Calls:        u.Calls + other.Calls,
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+	}
+}
+
+// UsageReporter is implemented by providers that track token usage
+type UsageReporter interface {
+	Usage() Usage
+}
+
+// usageTracker accumulates usage across calls, embedded by providers
+type usageTracker struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+func (t *usageTracker) record(input, output int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.usage = t.usage.Add(Usage{Calls: 1, InputTokens: input, OutputTokens: output})
+}
+
+func (t *usageTracker) Usage() Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.usage
+}
@@ -0,0 +1,230 @@
+package report
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/esifea/ai-driven-automation/internal/parser"
+	"github.com/esifea/ai-driven-automation/internal/provider"
+	"github.com/esifea/ai-driven-automation/internal/role"
+)
+
+const (
+	StatusSuccess  = "success"
+	StatusFailure  = "failure"
+	StatusCanceled = "canceled"
+)
+
+// Report collects what a run did, for GitHub outputs and the step summary
+type Report struct {
+	RunID         string               `json:"run_id"`
+	Mode          string               `json:"mode"`
+	TaskID        string               `json:"task_id,omitempty"`
+	Status        string               `json:"status"`
+	Stage         string               `json:"stage,omitempty"` // Last completed stage, tells how far a failed run got
+	Error         string               `json:"error,omitempty"`
+	FilesWritten  []string             `json:"files_written,omitempty"`
+	Changes       []parser.Change      `json:"changes,omitempty"`        // What happened to each file in FilesWritten
+	EditFailures  []string             `json:"edit_failures,omitempty"`  // Edit blocks that did not apply
+	RejectedPaths []string             `json:"rejected_paths,omitempty"` // Paths outside the repository or protected
+	OutOfScope    []string             `json:"out_of_scope,omitempty"`   // Files outside TARGET FILES and the analysis
+	ScopePolicy   string               `json:"scope_policy,omitempty"`   // What was done with them
+	SyntaxErrors  []string             `json:"syntax_errors,omitempty"`  // Files still failing validation after repair
+	Elisions      []string             `json:"elisions,omitempty"`       // Files still elided after re-prompting
+	Analysis      *role.AnalysisResult `json:"analysis,omitempty"`
+	Verdict       string               `json:"verdict,omitempty"` // PASS or FAIL (reviewer)
+	Review        string               `json:"review,omitempty"`
+	Usage         provider.Usage       `json:"usage"`
+}
+
+// WriteGitHubOutputs appends step outputs to the $GITHUB_OUTPUT file
+func (r *Report) WriteGitHubOutputs(path string) error {
+	outputs := []struct{ key, value string }{
+		{"run_id", r.RunID},
+		{"status", r.Status},
+		{"stage", r.Stage},
+		{"files_written", strconv.Itoa(len(r.FilesWritten))},
+		{"files", strings.Join(r.FilesWritten, "\n")},
+		{"review_result", r.Verdict},
+		{"pr_note", r.PRNote()},
+		{"tokens_input", strconv.Itoa(r.Usage.InputTokens)},
+		{"tokens_output", strconv.Itoa(r.Usage.OutputTokens)},
+	}
+
+	var b strings.Builder
+	for _, o := range outputs {
+		writeOutput(&b, o.key, o.value)
+	}
+
+	return appendFile(path, b.String())
+}
+
+// writeOutput uses the heredoc syntax for multiline values
+func writeOutput(b *strings.Builder, key, value string) {
+	if !strings.Contains(value, "\n") {
+		b.WriteString(fmt.Sprintf("%s=%s\n", key, value))
+		return
+	}
+
+	delim := "EOF_" + randomHex()
+	b.WriteString(fmt.Sprintf("%s<<%s\n%s\n%s\n", key, delim, value, delim))
+}
+
+// WriteStepSummary appends the markdown report to the $GITHUB_STEP_SUMMARY file
+func (r *Report) WriteStepSummary(path string) error {
+	return appendFile(path, r.Markdown())
+}
+
+func (r *Report) Markdown() string {
+	var b strings.Builder
+
+	title := fmt.Sprintf("## AI Agent: %s", r.Mode)
+	if r.TaskID != "" {
+		title += fmt.Sprintf(" (Task %s)", r.TaskID)
+	}
+	b.WriteString(title + "\n\n")
+
+	status := "✅ " + r.Status
+	if r.Status != StatusSuccess {
+		status = "❌ " + r.Status
+	}
+	b.WriteString("| Run ID | Status | Tokens (in / out) |\n")
+	b.WriteString("|--------|--------|-------------------|\n")
+	b.WriteString(fmt.Sprintf("| `%s` | %s | %d / %d |\n\n", r.RunID, status, r.Usage.InputTokens, r.Usage.OutputTokens))
+
+	if r.Error != "" {
+		b.WriteString(fmt.Sprintf("**Error:** `%s`\n\n", r.Error))
+	}
+
+	if r.Status != StatusSuccess && r.Stage != "" {
+		b.WriteString(fmt.Sprintf("**Stopped after stage:** `%s`\n\n", r.Stage))
+	}
+
+	if len(r.EditFailures) > 0 {
+		b.WriteString("### Failed Edits\n\n")
+		for _, f := range r.EditFailures {
+			b.WriteString(fmt.Sprintf("- %s\n", f))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.RejectedPaths) > 0 {
+		b.WriteString("### Rejected Paths\n\n")
+		for _, p := range r.RejectedPaths {
+			b.WriteString(fmt.Sprintf("- %s\n", p))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(r.PRNote())
+
+	if len(r.Elisions) > 0 {
+		b.WriteString("### Elided Files\n\n")
+		for _, e := range r.Elisions {
+			b.WriteString(fmt.Sprintf("- %s\n", e))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.SyntaxErrors) > 0 {
+		b.WriteString("### Syntax Errors\n\n```\n")
+		b.WriteString(strings.Join(r.SyntaxErrors, "\n"))
+		b.WriteString("\n```\n\n")
+	}
+
+	if len(r.FilesWritten) > 0 {
+		b.WriteString("### Changed Files\n\n")
+		actions := make(map[string]string)
+		for _, c := range r.Changes {
+			actions[c.Path] = c.Action
+		}
+		for _, f := range r.FilesWritten {
+			if action, ok := actions[f]; ok {
+				b.WriteString(fmt.Sprintf("- `%s` (%s)\n", f, action))
+			} else {
+				b.WriteString(fmt.Sprintf("- `%s`\n", f))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if r.Analysis != nil && (len(r.Analysis.FilesToModify) > 0 || len(r.Analysis.FilesToCreate) > 0 || len(r.Analysis.FilesToRead) > 0) {
+		b.WriteString("### Analysis Pass\n\n")
+		b.WriteString("| File | Action | Reason |\n")
+		b.WriteString("|------|--------|--------|\n")
+		writeActions(&b, "modify", r.Analysis.FilesToModify)
+		writeActions(&b, "create", r.Analysis.FilesToCreate)
+		writeActions(&b, "read", r.Analysis.FilesToRead)
+		b.WriteString("\n")
+	}
+
+	if r.Verdict != "" {
+		b.WriteString("### Reviewer Verdict\n\n")
+		if r.Verdict == "PASS" {
+			b.WriteString("**PASS** ✅\n\n")
+		} else {
+			b.WriteString("**CHANGES REQUESTED** ❌\n\n")
+		}
+		if r.Review != "" {
+			b.WriteString("<details><summary>Review</summary>\n\n")
+			b.WriteString(r.Review)
+			b.WriteString("\n\n</details>\n\n")
+		}
+	}
+
+	return b.String()
+}
+
+// PRNote lists the files outside the task scope for the PR description, empty if none
+func (r *Report) PRNote() string {
+	if len(r.OutOfScope) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("### Out-of-Scope Files\n\n")
+	switch r.ScopePolicy {
+	case parser.ScopeError:
+		b.WriteString("These files are outside the task's TARGET FILES and analysis, so nothing was written:\n\n")
+	case parser.ScopeNote:
+		b.WriteString(fmt.Sprintf("These files are outside the task's TARGET FILES and analysis and were left out. Their content is in `out_of_scope/` of run `%s`:\n\n", r.RunID))
+	default:
+		b.WriteString("These files are outside the task's TARGET FILES and analysis, please check them:\n\n")
+	}
+	for _, f := range r.OutOfScope {
+		b.WriteString(fmt.Sprintf("- `%s`\n", f))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func writeActions(b *strings.Builder, action string, files []role.FileAction) {
+	for _, f := range files {
+		reason := strings.ReplaceAll(f.Reason, "|", "\\|")
+		reason = strings.ReplaceAll(reason, "\n", " ")
+		b.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", f.Path, action, reason))
+	}
+}
+
+func appendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func randomHex() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,154 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/esifea/ai-driven-automation/internal/parser"
+	"github.com/esifea/ai-driven-automation/internal/provider"
+	"github.com/esifea/ai-driven-automation/internal/role"
+)
+
+func TestWriteGitHubOutputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+
+	r := &Report{
+		RunID:        "run-1",
+		Status:       StatusSuccess,
+		FilesWritten: []string{"a.go", "b.go"},
+		Verdict:      "PASS",
+		Usage:        provider.Usage{InputTokens: 100, OutputTokens: 20},
+	}
+	if err := r.WriteGitHubOutputs(path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+
+	for _, want := range []string{
+		"run_id=run-1\n",
+		"status=success\n",
+		"files_written=2\n",
+		"review_result=PASS\n",
+		"tokens_input=100\n",
+		"tokens_output=20\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q:\n%s", want, out)
+		}
+	}
+
+	// Multiline values use the heredoc syntax
+	if !strings.Contains(out, "files<<EOF_") || !strings.Contains(out, "\na.go\nb.go\nEOF_") {
+		t.Errorf("files should be written as a multiline value:\n%s", out)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	r := &Report{
+		RunID:        "run-1",
+		Mode:         "code",
+		TaskID:       "07",
+		Status:       StatusSuccess,
+		FilesWritten: []string{"internal/auth/handler.go"},
+		Analysis: &role.AnalysisResult{
+			FilesToModify: []role.FileAction{{Path: "internal/auth/handler.go", Reason: "add | login"}},
+		},
+		Verdict: "FAIL",
+		Review:  "STATUS: FAIL\n- missing tests",
+	}
+
+	md := r.Markdown()
+
+	for _, want := range []string{
+		"## AI Agent: code (Task 07)",
+		"- `internal/auth/handler.go`",
+		"| `internal/auth/handler.go` | modify | add \\| login |",
+		"**CHANGES REQUESTED**",
+		"- missing tests",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown should contain %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestMarkdown_Canceled(t *testing.T) {
+	r := &Report{
+		RunID:  "run-1",
+		Mode:   "code",
+		Status: StatusCanceled,
+		Stage:  "analysis",
+		Error:  "context canceled",
+	}
+
+	md := r.Markdown()
+
+	for _, want := range []string{
+		"❌ canceled",
+		"**Stopped after stage:** `analysis`",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown should contain %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestMarkdown_RejectedPaths(t *testing.T) {
+	r := &Report{
+		RunID:         "run-1",
+		Mode:          "code",
+		Status:        StatusFailure,
+		RejectedPaths: []string{"../x.go: outside the repository root"},
+	}
+
+	md := r.Markdown()
+
+	if !strings.Contains(md, "### Rejected Paths\n\n- ../x.go: outside the repository root") {
+		t.Errorf("markdown should list rejected paths:\n%s", md)
+	}
+}
+
+func TestMarkdown_Changes(t *testing.T) {
+	r := &Report{
+		RunID:        "run-1",
+		Mode:         "code",
+		Status:       StatusSuccess,
+		FilesWritten: []string{"new.go", "old.go"},
+		Changes: []parser.Change{
+			{Path: "new.go", Action: parser.ActionCreated},
+			{Path: "old.go", Action: parser.ActionDeleted},
+		},
+	}
+
+	md := r.Markdown()
+
+	if !strings.Contains(md, "- `new.go` (created)\n- `old.go` (deleted)\n") {
+		t.Errorf("markdown should show each change:\n%s", md)
+	}
+}
+
+func TestPRNote(t *testing.T) {
+	r := &Report{RunID: "run-1"}
+	if r.PRNote() != "" {
+		t.Error("no note without out-of-scope files")
+	}
+
+	r.OutOfScope, r.ScopePolicy = []string{"Makefile"}, parser.ScopeNote
+	note := r.PRNote()
+
+	for _, want := range []string{"### Out-of-Scope Files", "were left out", "run `run-1`", "- `Makefile`"} {
+		if !strings.Contains(note, want) {
+			t.Errorf("note should contain %q:\n%s", want, note)
+		}
+	}
+	if !strings.Contains(r.Markdown(), note) {
+		t.Error("step summary should include the note")
+	}
+}
@@ -36,7 +36,7 @@ type FileAction struct {
 }
 
 func RunAnalysis(ctx context.Context, provider provider.Provider, req *AnalysisRequest) (*AnalysisResult, error) {
-	prompt := buildAnalysisPrompt(req)
+	prompt := BuildAnalysisPrompt(req)
 
 	response, err := provider.Generate(ctx, prompt)
 	if err != nil {
@@ -46,7 +46,7 @@ func RunAnalysis(ctx context.Context, provider provider.Provider, req *AnalysisR
 	return parseAnalysisResult(response)
 }
 
-func buildAnalysisPrompt(req *AnalysisRequest) string {
+func BuildAnalysisPrompt(req *AnalysisRequest) string {
 	var b strings.Builder
 
 	b.WriteString("You are a Senior Engineer analyzing a codebase.\n\n")
@@ -0,0 +1,38 @@
+package role
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Turns beyond this are dropped from the prompt to bound its size
+const maxHistoryTurns = 10
+
+type QATurn struct {
+	Question string
+	Answer   string
+}
+
+// FormatConversation renders previous turns for inclusion in the Q&A context
+func FormatConversation(turns []QATurn) string {
+	if len(turns) == 0 {
+		return ""
+	}
+
+	if len(turns) > maxHistoryTurns {
+		turns = turns[len(turns)-maxHistoryTurns:]
+	}
+
+	var b strings.Builder
+	b.WriteString("=== CONVERSATION SO FAR ===\n\n")
+	for i, turn := range turns {
+		b.WriteString(fmt.Sprintf("--- Turn %d ---\n", i+1))
+		b.WriteString("USER:\n")
+		b.WriteString(turn.Question)
+		b.WriteString("\n\nASSISTANT:\n")
+		b.WriteString(turn.Answer)
+		b.WriteString("\n\n")
+	}
+
+	return b.String()
+}
@@ -3,19 +3,25 @@ package role
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/esifea/ai-driven-automation/internal/config"
+	"github.com/esifea/ai-driven-automation/internal/parser"
 	"github.com/esifea/ai-driven-automation/internal/provider"
 )
 
 func RunCoder(ctx context.Context, provider provider.Provider, cfg *config.Config, instruction, contextStr, overview string) (string, error) {
+	return provider.Generate(ctx, BuildCoderPrompt(cfg, instruction, contextStr, overview))
+}
+
+func BuildCoderPrompt(cfg *config.Config, instruction, contextStr, overview string) string {
 	feedback := cfg.Feedback
 	if feedback == "" {
 		feedback = "None."
 	}
 
-	prompt := fmt.Sprintf(`You are a Senior Engineer. Implement the following task.
+	return fmt.Sprintf(`You are a Senior Engineer. Implement the following task.
 
 GLOBAL PROJECT RULES (MUST FOLLOW):
 %s
@@ -27,22 +33,146 @@ TASK INSTRUCTIONS:
 %s
 
 REQUIREMENTS:
-1. Output the FULL content of any file you create or modify.
-2. Format:
-   ### File: path/to/file.ext
-   `+"```"+`
-   // content
-   `+"```"+`
+%s
 3. FIX issues from the FEEDBACK below (if any).
 4. Only modify files shown in CONTEXT - do not invent new paths.
+5. To delete or move a file, write a directive line outside any code block:
+   ### Delete: path/to/file.ext
+   ### Rename: old/path.ext -> new/path.ext
+   Changes to a renamed file go to its new path.
 
 PREVIOUS REVIEWER FEEDBACK:
-%s`, overview, contextStr, instruction, feedback)
+%s`, overview, contextStr, instruction, outputFormatInstructions(cfg.EditFormat), feedback)
+}
 
-	return provider.Generate(ctx, prompt)
+// outputFormatInstructions are requirements 1 and 2 of the coder prompt
+func outputFormatInstructions(format string) string {
+	switch format {
+	case parser.FormatSearchReplace:
+		return `1. Output only the changes, as SEARCH/REPLACE blocks. SEARCH must match the current file exactly,
+   including indentation, and be just long enough to be unique. Use several blocks for several changes.
+   For a new file, leave SEARCH empty and put the full content in REPLACE.
+2. Format:
+   ### File: path/to/file.ext
+   <<<<<<< SEARCH
+   existing lines
+   =======
+   new lines
+   >>>>>>> REPLACE`
+	case parser.FormatDiff:
+		return `1. Output only the changes, as a unified diff per file with at least 3 lines of context per hunk.
+   Context and removed lines must match the current file exactly. For a new file, use --- /dev/null.
+2. Format:
+   ` + "```diff" + `
+   --- a/path/to/file.ext
+   +++ b/path/to/file.ext
+   @@ -10,7 +10,8 @@
+    context
+   -removed line
+   +added line
+    context
+   ` + "```"
+	default:
+		return `1. Output the FULL content of any file you create or modify. If a file itself contains ` + "```" + ` fences
+   (e.g. markdown), open and close its block with a longer fence (` + "````" + `).
+2. Format:
+   ### File: path/to/file.ext
+   ` + "```" + `
+   // content
+   ` + "```"
+	}
+}
+
+// RunEditRetry asks the coder to redo only the edit blocks that failed to apply
+func RunEditRetry(ctx context.Context, provider provider.Provider, failures []parser.EditFailure, current map[string]string) (string, error) {
+	return provider.Generate(ctx, BuildEditRetryPrompt(failures, current))
+}
+
+func BuildEditRetryPrompt(failures []parser.EditFailure, current map[string]string) string {
+	var b strings.Builder
+	for _, f := range failures {
+		b.WriteString(fmt.Sprintf("### File: %s (%s)\n", f.Block.Path, f.Reason))
+		b.WriteString("<<<<<<< SEARCH\n")
+		b.WriteString(f.Block.Search)
+		b.WriteString("=======\n")
+		b.WriteString(f.Block.Replace)
+		b.WriteString(">>>>>>> REPLACE\n\n")
+	}
+
+	var files strings.Builder
+	for _, path := range sortedPaths(current) {
+		files.WriteString(fmt.Sprintf("### %s\n```\n%s\n```\n\n", path, current[path]))
+	}
+
+	return fmt.Sprintf(`You are a Senior Engineer. Some of your SEARCH/REPLACE blocks did not apply.
+
+FAILED BLOCKS:
+%s
+CURRENT FILE CONTENT (other blocks are already applied):
+%s
+Re-emit ONLY corrected blocks for the failed changes. SEARCH must match the current content exactly.
+Format:
+### File: path/to/file.ext
+<<<<<<< SEARCH
+existing lines
+=======
+new lines
+>>>>>>> REPLACE`, b.String(), files.String())
+}
+
+// RunSyntaxRepair asks the coder to fix files that do not parse
+func RunSyntaxRepair(ctx context.Context, provider provider.Provider, errs []parser.SyntaxError, files map[string]string) (string, error) {
+	return provider.Generate(ctx, BuildSyntaxRepairPrompt(errs, files))
+}
+
+func BuildSyntaxRepairPrompt(errs []parser.SyntaxError, files map[string]string) string {
+	var b strings.Builder
+	for _, e := range errs {
+		b.WriteString(fmt.Sprintf("### File: %s\nERRORS:\n%s\n````\n%s\n````\n\n", e.Path, e.Message, files[e.Path]))
+	}
+
+	return fmt.Sprintf(`You are a Senior Engineer. These files you generated have syntax errors.
+
+%s
+Fix ONLY the syntax errors, keeping the intended changes. Output the FULL corrected content of each file above, and no other file.
+Format:
+### File: path/to/file.ext
+`+"```"+`
+// content
+`+"```", b.String())
+}
+
+// RunElisionRepair asks the coder for the complete content of files it cut short
+func RunElisionRepair(ctx context.Context, provider provider.Provider, elisions []parser.Elision, files, originals map[string]string) (string, error) {
+	return provider.Generate(ctx, BuildElisionRepairPrompt(elisions, files, originals))
+}
+
+func BuildElisionRepairPrompt(elisions []parser.Elision, files, originals map[string]string) string {
+	var b strings.Builder
+	for _, e := range elisions {
+		b.WriteString(fmt.Sprintf("### File: %s\nPROBLEM: %s\n", e.Path, e.Reason))
+		if original := originals[e.Path]; original != "" {
+			b.WriteString(fmt.Sprintf("ORIGINAL:\n````\n%s\n````\n", original))
+		}
+		b.WriteString(fmt.Sprintf("GENERATED:\n````\n%s\n````\n\n", files[e.Path]))
+	}
+
+	return fmt.Sprintf(`You are a Senior Engineer. These files you generated look incomplete: they contain placeholders for omitted code or lost most of the original lines.
+
+%s
+Output the COMPLETE content of each file above, keeping your intended changes and every unchanged part of the original. Never use placeholders like "... rest of the file unchanged ...". Output no other file.
+Format:
+### File: path/to/file.ext
+`+"```"+`
+// content
+`+"```", b.String())
 }
 
 func RunQA(ctx context.Context, provider provider.Provider, cfg *config.Config, contextStr, overview string) (string, error) {
+	return provider.Generate(ctx, BuildQAPrompt(cfg, contextStr, overview))
+}
+
+func BuildQAPrompt(cfg *config.Config, contextStr, overview string) string {
 	userRequest := strings.Replace(cfg.PRQuestion, "/ask", "", 1)
 	userRequest = strings.TrimSpace(userRequest)
 
@@ -57,7 +187,7 @@ func RunQA(ctx context.Context, provider provider.Provider, cfg *config.Config,
 		targetInfo = fmt.Sprintf("\nTARGET FILE: %s\n%s\n", cfg.CommentPath, lineInfo)
 	}
 
-	prompt := fmt.Sprintf(`You are a Helpful Senior Engineer Assistant reviewing a Pull Request.
+	return fmt.Sprintf(`You are a Helpful Senior Engineer Assistant reviewing a Pull Request.
 
 GLOBAL PROJECT RULES:
 %s
@@ -76,12 +206,14 @@ INSTRUCTIONS:
 - If suggesting code changes, output the FULL file content using format:
   ### File: path/to/file.ext
 `, overview, contextStr, targetInfo, userRequest)
-
-	return provider.Generate(ctx, prompt)
 }
 
 func RunReviewer(ctx context.Context, provider provider.Provider, instruction, contextStr string) (string, error) {
-	prompt := fmt.Sprintf(`You are a Strict Code Reviewer (Principal Engineer).
+	return provider.Generate(ctx, BuildReviewerPrompt(instruction, contextStr))
+}
+
+func BuildReviewerPrompt(instruction, contextStr string) string {
+	return fmt.Sprintf(`You are a Strict Code Reviewer (Principal Engineer).
 
 Verify the code below against instructions:
 %s
@@ -92,6 +224,13 @@ GENERATED CODE:
 OUTPUT FORMAT:
 First line: STATUS: [PASS or FAIL]
 Subsequent lines: Bullet points of critique.`, instruction, contextStr)
+}
 
-	return provider.Generate(ctx, prompt)
+func sortedPaths(files map[string]string) []string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
 }
@@ -0,0 +1,41 @@
+package role
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/esifea/ai-driven-automation/internal/parser"
+)
+
+func TestBuildEditRetryPrompt_RejectedPath(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "repo")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "outside"), []byte("OUTSIDE_SECRET\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sandbox, err := parser.NewSandbox(root, parser.DefaultProtected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, failures := parser.ApplyEditBlocks(sandbox, []parser.EditBlock{
+		{Path: "../outside", Search: "x\n", Replace: "y\n"},
+	}, nil)
+	if len(failures) != 1 {
+		t.Fatalf("expected the block to be refused, got %v", failures)
+	}
+
+	prompt := BuildEditRetryPrompt(failures, parser.FailedFileContents(sandbox, files, failures))
+	if strings.Contains(prompt, "OUTSIDE_SECRET") {
+		t.Errorf("content outside the repository reached the prompt:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "### File: ../outside (rejected: outside the repository root)") {
+		t.Errorf("the refused block should be reported:\n%s", prompt)
+	}
+}
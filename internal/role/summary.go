@@ -15,6 +15,10 @@ type SummaryRequest struct {
 }
 
 func GenerateCompletionSummary(ctx context.Context, provider provider.Provider, req *SummaryRequest) (string, error) {
+	return provider.Generate(ctx, BuildSummaryPrompt(req))
+}
+
+func BuildSummaryPrompt(req *SummaryRequest) string {
 	fileList := ""
 	for path, content := range req.FilesChanged {
 		// FIXME: Include first 50 lines of each file for context (heuristic)
@@ -22,7 +26,7 @@ func GenerateCompletionSummary(ctx context.Context, provider provider.Provider,
 		fileList += fmt.Sprintf("### %s\n```\n%s\n```\n\n", path, lines)
 	}
 
-	prompt := fmt.Sprintf(`You are a technical documentation writer.
+	return fmt.Sprintf(`You are a technical documentation writer.
 
 A task has been completed and merged. Generate a completion summary for future reference.
 
@@ -59,8 +63,6 @@ PR: #%s
 
 Keep it concise but informative. Focus on what future tasks need to know.`,
 		req.TaskID, req.PRNumber, req.Instruction, fileList, req.TaskID, req.PRNumber)
-
-	return provider.Generate(ctx, prompt)
 }
 
 func truncateContent(content string, maxLines int) string {
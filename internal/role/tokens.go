@@ -0,0 +1,10 @@
+package role
+
+// EstimateTokens approximates the token count (~4 characters per token)
+func EstimateTokens(s string) int {
+	return EstimateTokensForSize(len(s))
+}
+
+func EstimateTokensForSize(chars int) int {
+	return (chars + 3) / 4
+}
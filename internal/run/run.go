@@ -0,0 +1,74 @@
+package run
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const DefaultRoot = ".agent/runs"
+
+// Run is a directory holding the artifacts of one agent invocation
+type Run struct {
+	ID  string
+	Dir string
+}
+
+// New creates the run directory, generating an ID if id is empty
+func New(root, id string) (*Run, error) {
+	if root == "" {
+		root = DefaultRoot
+	}
+	if id == "" {
+		id = NewID()
+	}
+
+	r := &Run{ID: id, Dir: filepath.Join(root, id)}
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	return r, nil
+}
+
+// Sub creates a nested run for one step of this run (e.g. a loop iteration),
+// with its own checkpoints; its ID is "<run-id>/<name>"
+func (r *Run) Sub(name string) (*Run, error) {
+	sub, err := New(r.Dir, name)
+	if err != nil {
+		return nil, err
+	}
+	sub.ID = r.ID + "/" + name
+	return sub, nil
+}
+
+// WriteFile stores an artifact relative to the run directory
+func (r *Run) WriteFile(name string, data []byte) error {
+	path := filepath.Join(r.Dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func (r *Run) WriteJSON(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return r.WriteFile(name, append(data, '\n'))
+}
+
+// NewID is sortable by start time: 20060102-150405-a1b2
+func NewID() string {
+	suffix := make([]byte, 2)
+	rand.Read(suffix)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
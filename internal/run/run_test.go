@@ -0,0 +1,86 @@
+package run
+
+import (
+	"testing"
+)
+
+func TestState_RoundTrip(t *testing.T) {
+	root := t.TempDir()
+
+	r, err := New(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	guards := &Guards{ProtectedPaths: "", ScopePolicy: "note", MaxShrink: 0}
+	if err := r.SaveState(&State{Mode: "code", TaskID: "auth/03", Guards: guards, Stage: StageResponse}); err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := Open(root, r.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := opened.LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.TaskID != "auth/03" || state.Stage != StageResponse || state.UpdatedAt.IsZero() {
+		t.Errorf("unexpected state: %+v", state)
+	}
+	// Empty guards are settings too, not missing ones
+	if state.Guards == nil || *state.Guards != *guards {
+		t.Errorf("unexpected guards: %+v", state.Guards)
+	}
+
+	if opened.Exists(ResponseFile) {
+		t.Error("response was never written")
+	}
+}
+
+func TestOpen_Missing(t *testing.T) {
+	if _, err := Open(t.TempDir(), "nope"); err == nil {
+		t.Error("opening a missing run should fail")
+	}
+}
+
+func TestStage_Reached(t *testing.T) {
+	if !StageFiles.Reached(StageAnalysis) {
+		t.Error("files is past analysis")
+	}
+	if StageAnalysis.Reached(StageResponse) {
+		t.Error("analysis is before the coder response")
+	}
+	if !StageDone.Reached(StageDone) {
+		t.Error("a stage reaches itself")
+	}
+}
+
+func TestSub(t *testing.T) {
+	root := t.TempDir()
+
+	r, err := New(root, "run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	iter, err := r.Sub("iter-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := iter.SaveState(&State{Mode: "loop", Stage: StageFiles}); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Exists(StateFile) {
+		t.Error("a nested run checkpoints in its own directory")
+	}
+
+	opened, err := Open(root, iter.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state, err := opened.LoadState(); err != nil || state.Stage != StageFiles {
+		t.Errorf("nested run should open by its ID: %v, %v", state, err)
+	}
+}
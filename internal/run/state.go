@@ -0,0 +1,121 @@
+package run
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Stage is the last completed step of a coder run
+type Stage string
+
+const (
+	StageStarted  Stage = "started"
+	StageAnalysis Stage = "analysis"       // analysis.json (absent if the pass failed)
+	StageResponse Stage = "coder_response" // coder_response.md
+	StageFiles    Stage = "files"          // files.json
+	StageDone     Stage = "done"           // Files written or dry-run printed
+)
+
+// Checkpoint file names inside the run directory
+const (
+	StateFile    = "state.json"
+	AnalysisFile = "analysis.json"
+	ResponseFile = "coder_response.md"
+	FilesFile    = "files.json"
+	DeletesFile  = "deletes.json" // Written with files.json when files are deleted
+	RenamesFile  = "renames.json" // Written with files.json when files are renamed
+)
+
+var stageOrder = []Stage{StageStarted, StageAnalysis, StageResponse, StageFiles, StageDone}
+
+// Reached reports whether s is at or past other
+func (s Stage) Reached(other Stage) bool {
+	return slices.Index(stageOrder, s) >= slices.Index(stageOrder, other)
+}
+
+// State records what is needed to resume a run
+type State struct {
+	Mode       string    `json:"mode"`
+	TaskID     string    `json:"task_id"`
+	Feedback   string    `json:"feedback,omitempty"`
+	DryRun     bool      `json:"dry_run,omitempty"`
+	PatchFile  string    `json:"patch_file,omitempty"`
+	EditFormat string    `json:"edit_format,omitempty"`
+	Guards     *Guards   `json:"guards,omitempty"` // nil in states saved before guards were recorded
+	Stage      Stage     `json:"stage"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Guards are the checks on coder output a resumed run must keep applying
+type Guards struct {
+	ProtectedPaths string  `json:"protected_paths"`
+	ScopePolicy    string  `json:"scope_policy"`
+	SyntaxChecks   string  `json:"syntax_checks"`
+	MaxShrink      float64 `json:"max_shrink"`
+}
+
+// Open loads an existing run directory
+func Open(root, id string) (*Run, error) {
+	if root == "" {
+		root = DefaultRoot
+	}
+
+	r := &Run{ID: id, Dir: filepath.Join(root, id)}
+	info, err := os.Stat(r.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("run %s not found in %s: %w", id, root, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("run %s is not a directory", r.Dir)
+	}
+
+	return r, nil
+}
+
+// SaveState checkpoints the stage reached
+func (r *Run) SaveState(state *State) error {
+	state.UpdatedAt = time.Now().UTC()
+	return r.WriteJSON(StateFile, state)
+}
+
+func (r *Run) LoadState() (*State, error) {
+	var state State
+	if err := r.ReadJSON(StateFile, &state); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(stageOrder, state.Stage) {
+		return nil, fmt.Errorf("unknown stage %q in %s", state.Stage, StateFile)
+	}
+	return &state, nil
+}
+
+func (r *Run) ReadFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(r.Dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+func (r *Run) ReadJSON(name string, v any) error {
+	data, err := r.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return nil
+}
+
+// Exists reports whether an artifact was saved
+func (r *Run) Exists(name string) bool {
+	_, err := os.Stat(filepath.Join(r.Dir, name))
+	return !errors.Is(err, fs.ErrNotExist)
+}